@@ -0,0 +1,358 @@
+package assembler
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/simomu-github/fflt_lang/executor"
+	"github.com/simomu-github/fflt_lang/lexer"
+	"github.com/simomu-github/fflt_lang/parser"
+)
+
+type operand string
+
+const (
+	operandNone   = operand("none")
+	operandNumber = operand("number")
+	operandIndex  = operand("index")
+	operandLabel  = operand("label")
+)
+
+// mnemonics are the names printed by Instruction.Disassenble.
+var mnemonics = map[string]operand{
+	"PUSH":           operandNumber,
+	"COPY":           operandIndex,
+	"SLIDE":          operandIndex,
+	"DUP":            operandNone,
+	"SWAP":           operandNone,
+	"DISCARD":        operandNone,
+	"ADD":            operandNone,
+	"SUB":            operandNone,
+	"MUL":            operandNone,
+	"DIV":            operandNone,
+	"MOD":            operandNone,
+	"STORE":          operandNone,
+	"RETRIEVE":       operandNone,
+	"LABEL":          operandLabel,
+	"CALLSUB":        operandLabel,
+	"JUMP":           operandLabel,
+	"JUMP_WHEN_ZERO": operandLabel,
+	"JUMP_WHEN_NEGA": operandLabel,
+	"ENDSUB":         operandNone,
+	"END":            operandNone,
+	"PUTC":           operandNone,
+	"PUTN":           operandNone,
+	"GETC":           operandNone,
+	"GETN":           operandNone,
+}
+
+type line struct {
+	token    lexer.Token
+	mnemonic string
+	operand  string
+}
+
+type assembleState struct {
+	filename string
+	labels   map[string]string
+}
+
+// Assemble translates the mnemonic format printed by -dump into canonical
+// FFLT source.
+func Assemble(source string, filename string) (string, error) {
+	instructions, err := Parse(source, filename)
+	if err != nil {
+		return "", err
+	}
+
+	return Format(instructions), nil
+}
+
+// Parse reads one instruction per line. A line may start with the
+// instruction index printed by -dump, and "#" or ";" starts a comment. A
+// label instruction without a label has the empty label.
+// Labels which are not made of F and L are given unused F/L names.
+// Undefined and duplicate labels are errors, but jump targets are not
+// resolved.
+func Parse(source string, filename string) ([]executor.Instruction, error) {
+	var errs lexer.ErrorList
+
+	lines := []line{}
+	for i, text := range strings.Split(source, "\n") {
+		l, ok, err := parseLine(filename, i+1, text)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if ok {
+			lines = append(lines, l)
+		}
+	}
+
+	state := assembleState{filename: filename, labels: allocateLabels(lines)}
+	instructions := []executor.Instruction{}
+	for _, l := range lines {
+		instruction, err := state.instruction(l)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		instructions = append(instructions, instruction)
+	}
+	errs = append(errs, labelErrors(lines, filename)...)
+
+	if len(errs) > 0 {
+		sortErrors(errs)
+		return nil, errs
+	}
+	return instructions, nil
+}
+
+// labelErrors reports the undefined and duplicate labels which the parser
+// diagnoses, with the labels as they are written.
+func labelErrors(lines []line, filename string) lexer.ErrorList {
+	state := assembleState{filename: filename, labels: map[string]string{}}
+	instructions := []executor.Instruction{}
+	for _, l := range lines {
+		if mnemonics[l.mnemonic] == operandLabel {
+			state.labels[l.operand] = l.operand
+			instruction, _ := state.instruction(l)
+			instructions = append(instructions, instruction)
+		}
+	}
+
+	var errs lexer.ErrorList
+	for _, diagnostic := range parser.Diagnose(instructions, filename) {
+		if diagnostic.Severity != parser.SeverityError {
+			continue
+		}
+
+		message := diagnostic.Message
+		for _, related := range diagnostic.Related {
+			message += fmt.Sprintf(" (see line %d)", related.Line)
+		}
+		errs = append(errs, &lexer.SyntaxError{
+			Filename: filename,
+			Line:     diagnostic.Position.Line,
+			Column:   diagnostic.Position.Column,
+			Message:  message,
+		})
+	}
+	return errs
+}
+
+// sortErrors orders errs by position since errors in operands are found
+// after errors in mnemonics.
+func sortErrors(errs lexer.ErrorList) {
+	sort.SliceStable(errs, func(i, j int) bool {
+		a, b := errs[i].(*lexer.SyntaxError), errs[j].(*lexer.SyntaxError)
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+}
+
+func parseLine(filename string, lineNumber int, text string) (line, bool, error) {
+	if i := strings.IndexAny(text, "#;"); i >= 0 {
+		text = text[:i]
+	}
+
+	fields, columns := splitFields(text)
+	if len(fields) > 0 && isIndex(fields[0]) {
+		fields, columns = fields[1:], columns[1:]
+	}
+	if len(fields) == 0 {
+		return line{}, false, nil
+	}
+
+	token := lexer.Token{Literal: fields[0], Line: lineNumber, Column: columns[0]}
+	kind, ok := mnemonics[strings.ToUpper(fields[0])]
+	if !ok {
+		return line{}, false, assembleError(filename, token, fmt.Sprintf("unknown mnemonic \"%s\"", fields[0]))
+	}
+
+	l := line{token: token, mnemonic: strings.ToUpper(fields[0])}
+	switch {
+	case kind == operandNone && len(fields) > 1:
+		token.Column = columns[1]
+		return line{}, false, assembleError(filename, token, fmt.Sprintf("%s takes no parameter", l.mnemonic))
+	case kind != operandNone && kind != operandLabel && len(fields) < 2:
+		return line{}, false, assembleError(filename, token, fmt.Sprintf("%s expects a %s parameter", l.mnemonic, kind))
+	case len(fields) > 2:
+		token.Column = columns[2]
+		return line{}, false, assembleError(filename, token, fmt.Sprintf("unexpected \"%s\"", fields[2]))
+	case len(fields) == 2:
+		l.operand = fields[1]
+	}
+
+	return l, true, nil
+}
+
+func splitFields(text string) ([]string, []int) {
+	fields := []string{}
+	columns := []int{}
+	start := -1
+	column := 0
+	for i, char := range text {
+		column++
+		if unicode.IsSpace(char) {
+			if start >= 0 {
+				fields = append(fields, text[start:i])
+				start = -1
+			}
+			continue
+		}
+		if start < 0 {
+			start = i
+			columns = append(columns, column)
+		}
+	}
+	if start >= 0 {
+		fields = append(fields, text[start:])
+	}
+	return fields, columns
+}
+
+func isIndex(field string) bool {
+	for _, char := range field {
+		if char < '0' || char > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func isFLLabel(label string) bool {
+	for _, char := range label {
+		if !strings.ContainsRune("FfLl", char) {
+			return false
+		}
+	}
+	return true
+}
+
+// allocateLabels maps each label to its F/L name. Named labels get the
+// shortest F/L names which are not used in order of their first appearance.
+func allocateLabels(lines []line) map[string]string {
+	labels := map[string]string{}
+	used := map[string]bool{}
+	for _, l := range lines {
+		if mnemonics[l.mnemonic] == operandLabel && isFLLabel(l.operand) {
+			labels[l.operand] = l.operand
+			used[l.operand] = true
+		}
+	}
+
+	next := 0
+	for _, l := range lines {
+		if mnemonics[l.mnemonic] != operandLabel {
+			continue
+		}
+		if _, ok := labels[l.operand]; ok {
+			continue
+		}
+
+		for {
+			next++
+			name := flName(next)
+			if !used[name] {
+				labels[l.operand] = name
+				used[name] = true
+				break
+			}
+		}
+	}
+
+	return labels
+}
+
+// flName returns the nth F/L string in order of length, i.e. F, L, FF, FL, ...
+func flName(n int) string {
+	bits := strconv.FormatInt(int64(n+1), 2)[1:]
+	return strings.NewReplacer("0", lexer.UpperF, "1", lexer.UpperL).Replace(bits)
+}
+
+func (state assembleState) instruction(l line) (executor.Instruction, error) {
+	token := l.token
+	source := executor.NewSource(token, lexer.Span{})
+
+	switch mnemonics[l.mnemonic] {
+	case operandNumber:
+		value, ok := executor.ParseNumber(l.operand)
+		if !ok {
+			return nil, assembleError(state.filename, token, fmt.Sprintf("invalid number \"%s\"", l.operand))
+		}
+		return executor.Push{Source: source, Value: value}, nil
+	case operandIndex:
+		value, err := strconv.Atoi(l.operand)
+		if err != nil {
+			return nil, assembleError(state.filename, token, fmt.Sprintf("invalid index \"%s\"", l.operand))
+		}
+		if l.mnemonic == "COPY" {
+			return executor.Copy{Source: source, Value: value}, nil
+		}
+		return executor.Slide{Source: source, Value: value}, nil
+	case operandLabel:
+		label := state.labels[l.operand]
+		switch l.mnemonic {
+		case "LABEL":
+			return executor.MarkLabel{Source: source, Label: label}, nil
+		case "CALLSUB":
+			return executor.CallSubroutine{Source: source, Label: label}, nil
+		case "JUMP":
+			return executor.JumpLabel{Source: source, Label: label}, nil
+		case "JUMP_WHEN_ZERO":
+			return executor.JumpLabelWhenZero{Source: source, Label: label}, nil
+		default:
+			return executor.JumpLabelWhenNegative{Source: source, Label: label}, nil
+		}
+	}
+
+	switch l.mnemonic {
+	case "DUP":
+		return executor.Duplicate{Source: source}, nil
+	case "SWAP":
+		return executor.Swap{Source: source}, nil
+	case "DISCARD":
+		return executor.Discard{Source: source}, nil
+	case "ADD":
+		return executor.Addition{Source: source}, nil
+	case "SUB":
+		return executor.Subtraction{Source: source}, nil
+	case "MUL":
+		return executor.Multiplication{Source: source}, nil
+	case "DIV":
+		return executor.Division{Source: source}, nil
+	case "MOD":
+		return executor.Modulo{Source: source}, nil
+	case "STORE":
+		return executor.Store{Source: source}, nil
+	case "RETRIEVE":
+		return executor.Retrieve{Source: source}, nil
+	case "ENDSUB":
+		return executor.EndSubroutine{Source: source}, nil
+	case "END":
+		return executor.EndProgram{Source: source}, nil
+	case "PUTC":
+		return executor.Putc{Source: source}, nil
+	case "PUTN":
+		return executor.Putn{Source: source}, nil
+	case "GETC":
+		return executor.Getc{Source: source}, nil
+	default:
+		return executor.Getn{Source: source}, nil
+	}
+}
+
+func assembleError(filename string, token lexer.Token, message string) error {
+	return &lexer.SyntaxError{
+		Filename: filename,
+		Line:     token.Line,
+		Column:   token.Column,
+		Message:  message,
+	}
+}
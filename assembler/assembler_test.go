@@ -0,0 +1,92 @@
+package assembler
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/simomu-github/fflt_lang/executor"
+	"github.com/simomu-github/fflt_lang/lexer"
+	"github.com/simomu-github/fflt_lang/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+func dump(t *testing.T, source string) string {
+	tokens, err := lexer.ScanAllTokens(source, "test.fflt")
+	assert.NoError(t, err)
+	instructions, labelMap, err := parser.ParseAll(tokens, "test.fflt")
+	assert.NoError(t, err)
+
+	output := &bytes.Buffer{}
+	exe := executor.Executor{Instructions: instructions, LabelMap: labelMap, Output: output}
+	assert.NoError(t, exe.Disassenble())
+	return output.String()
+}
+
+func TestAssembleRoundTrip(t *testing.T) {
+	filenames, _ := filepath.Glob("../samples/*.fflt")
+	assert.NotEmpty(t, filenames)
+
+	for _, filename := range filenames {
+		t.Run(filepath.Base(filename), func(t *testing.T) {
+			source, err := os.ReadFile(filename)
+			assert.NoError(t, err)
+
+			disassembly := dump(t, string(source))
+			assembled, err := Assemble(disassembly, "test.asm")
+			assert.NoError(t, err)
+			assert.Equal(t, disassembly, dump(t, assembled))
+		})
+	}
+}
+
+func TestAssemble(t *testing.T) {
+	source := `# named labels are given unused F/L names
+0000 push 0      ; index and comment
+	PUTN
+LABEL loop
+PUSH -5
+COPY 0
+SLIDE 1
+JUMP_WHEN_NEGA F
+LABEL F
+CALLSUB  loop
+END
+`
+
+	assembled, err := Assemble(source, "test.asm")
+	assert.NoError(t, err)
+	assert.Equal(t, "FFFFT\nLTFL\nTFFLT\nFFLLFLT\nFLFFFT\nFLTFLT\nTLLFT\nTFFFT\nTFLLT\nTTT\n", assembled)
+}
+
+func TestAssembleEmptyLabel(t *testing.T) {
+	source := "PUSH 0\nLABEL\nJUMP_WHEN_ZERO\n"
+
+	assembled, err := Assemble(source, "test.asm")
+	assert.NoError(t, err)
+	assert.Equal(t, "FFFFT\nTFFT\nTLFT\n", assembled)
+	assert.Equal(t, "0000 PUSH           0\n0001 LABEL          \n0002 JUMP_WHEN_ZERO \n", dump(t, assembled))
+}
+
+func TestAssembleErrors(t *testing.T) {
+	_, err := Assemble("PUSH\nPOP\nDUP 1\nPUSH x\nCOPY 1 2\n", "test.asm")
+
+	assert.Equal(t, "Syntex error: PUSH expects a number parameter at test.asm:1:1\n"+
+		"Syntex error: unknown mnemonic \"POP\" at test.asm:2:1\n"+
+		"Syntex error: DUP takes no parameter at test.asm:3:5\n"+
+		"Syntex error: invalid number \"x\" at test.asm:4:1\n"+
+		"Syntex error: unexpected \"2\" at test.asm:5:8", err.Error())
+}
+
+func TestAssembleLabelErrors(t *testing.T) {
+	_, err := Assemble("LABEL loop\nJUMP done\n# again\nLABEL loop\n", "test.asm")
+
+	assert.Equal(t, "Syntex error: label \"done\" is not found at test.asm:2:1\n"+
+		"Syntex error: label \"loop\" is already defined (see line 1) at test.asm:4:1", err.Error())
+}
+
+func TestFlName(t *testing.T) {
+	assert.Equal(t, []string{"F", "L", "FF", "FL", "LF", "LL", "FFF"},
+		[]string{flName(1), flName(2), flName(3), flName(4), flName(5), flName(6), flName(7)})
+}
@@ -0,0 +1,92 @@
+package assembler
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/simomu-github/fflt_lang/executor"
+	"github.com/simomu-github/fflt_lang/lexer"
+)
+
+// Format writes instructions as FFLT source, one instruction per line in
+// upper case. Labels keep their case.
+func Format(instructions []executor.Instruction) string {
+	var builder strings.Builder
+	for _, instruction := range instructions {
+		builder.WriteString(formatInstruction(instruction))
+		builder.WriteString(lexer.LF)
+	}
+	return builder.String()
+}
+
+func formatInstruction(instruction executor.Instruction) string {
+	switch ins := instruction.(type) {
+	case executor.Push:
+		return "FF" + formatNumber(ins.Value.Big())
+	case executor.Copy:
+		return "FLF" + formatNumber(big.NewInt(int64(ins.Value)))
+	case executor.Slide:
+		return "FLT" + formatNumber(big.NewInt(int64(ins.Value)))
+	case executor.Duplicate:
+		return "FTF"
+	case executor.Swap:
+		return "FTL"
+	case executor.Discard:
+		return "FTT"
+
+	case executor.Addition:
+		return "LFFF"
+	case executor.Subtraction:
+		return "LFFL"
+	case executor.Multiplication:
+		return "LFFT"
+	case executor.Division:
+		return "LFLF"
+	case executor.Modulo:
+		return "LFLL"
+
+	case executor.Store:
+		return "LLF"
+	case executor.Retrieve:
+		return "LLL"
+
+	case executor.MarkLabel:
+		return "TFF" + ins.Label + lexer.UpperT
+	case executor.CallSubroutine:
+		return "TFL" + ins.Label + lexer.UpperT
+	case executor.JumpLabel:
+		return "TFT" + ins.Label + lexer.UpperT
+	case executor.JumpLabelWhenZero:
+		return "TLF" + ins.Label + lexer.UpperT
+	case executor.JumpLabelWhenNegative:
+		return "TLL" + ins.Label + lexer.UpperT
+	case executor.EndSubroutine:
+		return "TLT"
+	case executor.EndProgram:
+		return "TTT"
+
+	case executor.Putc:
+		return "LTFF"
+	case executor.Putn:
+		return "LTFL"
+	case executor.Getc:
+		return "LTLF"
+	case executor.Getn:
+		return "LTLL"
+	}
+
+	return ""
+}
+
+// formatNumber writes the sign, the bits and the terminating T of n. A
+// number without bits reads as zero too, but zero is written with a single F
+// bit so that the number is not easily mistaken for a missing one.
+func formatNumber(n *big.Int) string {
+	sign := lexer.UpperF
+	if n.Sign() < 0 {
+		sign = lexer.UpperL
+	}
+
+	bits := new(big.Int).Abs(n).Text(2)
+	return sign + strings.NewReplacer("0", lexer.UpperF, "1", lexer.UpperL).Replace(bits) + lexer.UpperT
+}
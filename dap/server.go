@@ -0,0 +1,436 @@
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/simomu-github/fflt_lang/executor"
+	"github.com/simomu-github/fflt_lang/lexer"
+	"github.com/simomu-github/fflt_lang/parser"
+)
+
+const (
+	threadID                = 1
+	stackVariablesReference = 1
+	heapVariablesReference  = 2
+)
+
+// Server is a Debug Adapter Protocol server which debugs one FFLT program
+// with executor.Debugger. The program runs on its own goroutine so that pause
+// and disconnect are handled while it runs; other requests wait until it
+// stops.
+type Server struct {
+	reader      *bufio.Reader
+	writer      io.Writer
+	mu          sync.Mutex // guards seq and writer, which the program writes to as well
+	seq         int
+	executor    *executor.Executor
+	debugger    *executor.Debugger
+	stopOnEntry bool
+	// running is closed when the program stops, and runErr is the error of
+	// reporting where it stopped. It is nil while the program is not run.
+	running      chan struct{}
+	runErr       error
+	disconnected atomic.Bool
+}
+
+func NewServer(reader io.Reader, writer io.Writer) *Server {
+	return &Server{
+		reader: bufio.NewReader(reader),
+		writer: writer,
+	}
+}
+
+// Serve handles requests until the client disconnects or closes the input.
+func (s *Server) Serve() error {
+	for {
+		body, err := readMessage(s.reader)
+		if errors.Is(err, io.EOF) {
+			return s.stop()
+		}
+		if err != nil {
+			return err
+		}
+
+		req := request{}
+		if err := json.Unmarshal(body, &req); err != nil {
+			return fmt.Errorf("invalid message: %w", err)
+		}
+
+		switch req.Command {
+		case "disconnect":
+			if err := s.stop(); err != nil {
+				return err
+			}
+			return s.respond(req, nil)
+		case "pause":
+			if s.running == nil {
+				err = s.respondError(req, "program is not running")
+			} else if err = s.respond(req, nil); err == nil {
+				s.debugger.Pause()
+			}
+		default:
+			if err = s.wait(); err == nil {
+				err = s.handle(req)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// run runs the debugger on its own goroutine and reports where it stopped,
+// except for the pause which cancels the program on disconnect.
+func (s *Server) run(run func(*executor.Debugger) executor.StopReason) {
+	running := make(chan struct{})
+	s.running = running
+	go func() {
+		defer close(running)
+		reason := run(s.debugger)
+		if reason == executor.StopReasonPause && s.disconnected.Load() {
+			return
+		}
+		s.runErr = s.reportStop(reason)
+	}()
+}
+
+// stop cancels the program if it is running.
+func (s *Server) stop() error {
+	s.disconnected.Store(true)
+	if s.running != nil {
+		s.debugger.Pause()
+	}
+	return s.wait()
+}
+
+// wait waits until the program stops if it is running.
+func (s *Server) wait() error {
+	if s.running == nil {
+		return nil
+	}
+
+	<-s.running
+	s.running = nil
+	return s.runErr
+}
+
+func (s *Server) handle(req request) error {
+	switch req.Command {
+	case "initialize":
+		return s.respond(req, map[string]any{
+			"supportsConfigurationDoneRequest": true,
+			"supportsConditionalBreakpoints":   true,
+		})
+	case "launch":
+		return s.launch(req)
+	case "setBreakpoints":
+		return s.setBreakpoints(req)
+	case "setExceptionBreakpoints":
+		return s.respond(req, nil)
+	case "configurationDone":
+		if s.debugger == nil {
+			return s.respondError(req, "program is not launched")
+		}
+		if err := s.respond(req, nil); err != nil {
+			return err
+		}
+		s.run(func(d *executor.Debugger) executor.StopReason { return d.Start(s.stopOnEntry) })
+		return nil
+	case "threads":
+		return s.respond(req, map[string]any{
+			"threads": []thread{{ID: threadID, Name: "main"}},
+		})
+	case "stackTrace":
+		return s.stackTrace(req)
+	case "scopes":
+		return s.respond(req, map[string]any{
+			"scopes": []scope{
+				{Name: "Stack", VariablesReference: stackVariablesReference},
+				{Name: "Heap", VariablesReference: heapVariablesReference},
+			},
+		})
+	case "variables":
+		return s.variables(req)
+	case "continue":
+		return s.resume(req, map[string]any{"allThreadsContinued": true}, (*executor.Debugger).Continue)
+	case "next":
+		return s.resume(req, nil, (*executor.Debugger).Next)
+	case "stepIn":
+		return s.resume(req, nil, (*executor.Debugger).Step)
+	case "stepOut":
+		return s.resume(req, nil, (*executor.Debugger).Finish)
+	default:
+		return s.respondError(req, fmt.Sprintf("%s is not supported", req.Command))
+	}
+}
+
+func (s *Server) launch(req request) error {
+	args := launchArguments{}
+	if err := json.Unmarshal(req.Arguments, &args); err != nil {
+		return s.respondError(req, err.Error())
+	}
+
+	exe, err := load(args.Program)
+	if err != nil {
+		return s.respondError(req, err.Error())
+	}
+
+	s.executor = exe
+	s.debugger = executor.NewDebugger(exe)
+	s.debugger.ClearBreakpoints()
+	s.stopOnEntry = args.StopOnEntry
+	s.debugger.Stdout = &outputWriter{server: s, category: "console"}
+	s.debugger.Stderr = &outputWriter{server: s, category: "stderr"}
+	exe.Input = strings.NewReader(args.Input)
+	exe.Output = &outputWriter{server: s, category: "stdout"}
+
+	if err := s.respond(req, nil); err != nil {
+		return err
+	}
+	return s.sendEvent("initialized", nil)
+}
+
+func load(filename string) (*executor.Executor, error) {
+	instructions, labelMap, err := parser.ParseFile(filename, lexer.FileAlphabet(filename))
+	if err != nil {
+		return nil, err
+	}
+
+	return &executor.Executor{
+		Filename:     filename,
+		Instructions: instructions,
+		LabelMap:     labelMap,
+	}, nil
+}
+
+func (s *Server) setBreakpoints(req request) error {
+	args := setBreakpointsArguments{}
+	if err := json.Unmarshal(req.Arguments, &args); err != nil {
+		return s.respondError(req, err.Error())
+	}
+
+	breakpoints := []breakpoint{}
+	if s.debugger == nil || !samePath(args.Source.Path, s.executor.Filename) {
+		for range args.Breakpoints {
+			breakpoints = append(breakpoints, breakpoint{Verified: false, Message: "source is not launched"})
+		}
+		return s.respond(req, map[string]any{"breakpoints": breakpoints})
+	}
+
+	s.debugger.ClearBreakpoints()
+	for _, b := range args.Breakpoints {
+		index, ok := s.debugger.LineInstruction(b.Line)
+		if !ok {
+			breakpoints = append(breakpoints, breakpoint{Verified: false, Message: "no instruction at or after the line"})
+			continue
+		}
+		if err := s.debugger.SetBreakpoint(index, b.Condition); err != nil {
+			breakpoints = append(breakpoints, breakpoint{Verified: false, Message: err.Error()})
+			continue
+		}
+
+		line := s.executor.Instructions[index].Span().Start.Line
+		breakpoints = append(breakpoints, breakpoint{Verified: true, Line: line})
+	}
+
+	return s.respond(req, map[string]any{"breakpoints": breakpoints})
+}
+
+func (s *Server) stackTrace(req request) error {
+	if s.debugger == nil {
+		return s.respondError(req, "program is not launched")
+	}
+
+	snapshot := s.executor.Snapshot()
+	frames := []stackFrame{}
+	for depth := len(snapshot.CallStack); depth >= 0; depth-- {
+		index := snapshot.ProgramCounter
+		if depth < len(snapshot.CallStack) {
+			index = snapshot.CallStack[depth]
+		}
+		frames = append(frames, s.stackFrame(len(frames), depth, index, snapshot.CallStack))
+	}
+
+	return s.respond(req, map[string]any{
+		"stackFrames": frames,
+		"totalFrames": len(frames),
+	})
+}
+
+// stackFrame builds the frame at depth of the call stack, which is running
+// the instruction at index. Frames are named after the label of the call
+// which entered them.
+func (s *Server) stackFrame(id, depth, index int, callStack []int) stackFrame {
+	name := "main"
+	if depth > 0 {
+		if call, ok := s.executor.Instructions[callStack[depth-1]].(executor.CallSubroutine); ok {
+			name = call.Label
+		}
+	}
+
+	frame := stackFrame{
+		ID:     id,
+		Name:   name,
+		Source: source{Name: filepath.Base(s.executor.Filename), Path: s.executor.Filename},
+	}
+	if index < len(s.executor.Instructions) {
+		start := s.executor.Instructions[index].Span().Start
+		frame.Line = start.Line
+		frame.Column = start.Column
+	}
+	return frame
+}
+
+func (s *Server) variables(req request) error {
+	args := variablesArguments{}
+	if err := json.Unmarshal(req.Arguments, &args); err != nil {
+		return s.respondError(req, err.Error())
+	}
+	if s.debugger == nil {
+		return s.respondError(req, "program is not launched")
+	}
+
+	snapshot := s.executor.Snapshot()
+	variables := []variable{}
+	switch args.VariablesReference {
+	case stackVariablesReference:
+		for i := len(snapshot.Stack) - 1; i >= 0; i-- {
+			variables = append(variables, variable{
+				Name:  fmt.Sprintf("[%d]", len(snapshot.Stack)-1-i),
+				Value: snapshot.Stack[i].String(),
+			})
+		}
+	case heapVariablesReference:
+		for _, cell := range snapshot.Heap {
+			variables = append(variables, variable{
+				Name:  fmt.Sprintf("[%s]", cell.Address),
+				Value: cell.Value.String(),
+			})
+		}
+	}
+
+	return s.respond(req, map[string]any{"variables": variables})
+}
+
+// resume responds to req and runs the debugger, which reports where it
+// stopped. After a runtime error the program can not continue, so it is
+// terminated.
+func (s *Server) resume(req request, body any, run func(*executor.Debugger) executor.StopReason) error {
+	if s.debugger == nil {
+		return s.respondError(req, "program is not launched")
+	}
+	if err := s.respond(req, body); err != nil {
+		return err
+	}
+
+	if s.debugger.Err() != nil {
+		return s.terminate(1)
+	}
+	s.run(run)
+	return nil
+}
+
+func (s *Server) reportStop(reason executor.StopReason) error {
+	switch reason {
+	case executor.StopReasonExited:
+		return s.terminate(0)
+	case executor.StopReasonException:
+		return s.sendEvent("stopped", map[string]any{
+			"reason":            string(reason),
+			"description":       "Runtime error",
+			"text":              s.debugger.Err().Error(),
+			"threadId":          threadID,
+			"allThreadsStopped": true,
+		})
+	default:
+		return s.sendEvent("stopped", map[string]any{
+			"reason":            string(reason),
+			"threadId":          threadID,
+			"allThreadsStopped": true,
+		})
+	}
+}
+
+func (s *Server) terminate(exitCode int) error {
+	if err := s.sendEvent("exited", map[string]any{"exitCode": exitCode}); err != nil {
+		return err
+	}
+	return s.sendEvent("terminated", nil)
+}
+
+func (s *Server) respond(req request, body any) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.seq++
+	return writeMessage(s.writer, response{
+		Seq:        s.seq,
+		Type:       "response",
+		RequestSeq: req.Seq,
+		Success:    true,
+		Command:    req.Command,
+		Body:       body,
+	})
+}
+
+func (s *Server) respondError(req request, message string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.seq++
+	return writeMessage(s.writer, response{
+		Seq:        s.seq,
+		Type:       "response",
+		RequestSeq: req.Seq,
+		Success:    false,
+		Command:    req.Command,
+		Message:    message,
+	})
+}
+
+func (s *Server) sendEvent(name string, body any) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.seq++
+	return writeMessage(s.writer, event{
+		Seq:   s.seq,
+		Type:  "event",
+		Event: name,
+		Body:  body,
+	})
+}
+
+type outputWriter struct {
+	server   *Server
+	category string
+}
+
+func (o *outputWriter) Write(p []byte) (int, error) {
+	err := o.server.sendEvent("output", map[string]any{
+		"category": o.category,
+		"output":   string(p),
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func samePath(a, b string) bool {
+	if a == b {
+		return true
+	}
+
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && absA == absB
+}
@@ -0,0 +1,206 @@
+package dap
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// push 2, call F, end, label F, putn, return
+const subroutineProgram = "FFFLFT\nTFLFT\nTTT\nTFFFT\nLTFL\nTLT\n"
+
+func writeProgram(t *testing.T, source string) string {
+	filename := filepath.Join(t.TempDir(), "test.fflt")
+	assert.NoError(t, os.WriteFile(filename, []byte(source), 0644))
+	return filename
+}
+
+func requests(t *testing.T, messages ...map[string]any) *bytes.Buffer {
+	buffer := &bytes.Buffer{}
+	for i, message := range messages {
+		message["seq"] = i + 1
+		message["type"] = "request"
+		assert.NoError(t, writeMessage(buffer, message))
+	}
+	return buffer
+}
+
+func serve(t *testing.T, input *bytes.Buffer) []map[string]any {
+	output := &bytes.Buffer{}
+	assert.NoError(t, NewServer(input, output).Serve())
+
+	messages := []map[string]any{}
+	reader := bufio.NewReader(output)
+	for {
+		body, err := readMessage(reader)
+		if err != nil {
+			break
+		}
+		message := map[string]any{}
+		assert.NoError(t, json.Unmarshal(body, &message))
+		messages = append(messages, message)
+	}
+	return messages
+}
+
+func kinds(messages []map[string]any) []string {
+	result := []string{}
+	for _, message := range messages {
+		if message["type"] == "response" {
+			result = append(result, message["command"].(string))
+		} else {
+			result = append(result, message["event"].(string)+" event")
+		}
+	}
+	return result
+}
+
+func TestReadMessage(t *testing.T) {
+	reader := bufio.NewReader(bytes.NewBufferString("Content-Length: 2\r\n\r\n{}Content-Length: 3\r\n\r\n[1]"))
+
+	body, err := readMessage(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, "{}", string(body))
+
+	body, err = readMessage(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, "[1]", string(body))
+
+	_, err = readMessage(bufio.NewReader(bytes.NewBufferString("\r\n{}")))
+	assert.Error(t, err)
+}
+
+func TestServe(t *testing.T) {
+	filename := writeProgram(t, subroutineProgram)
+
+	messages := serve(t, requests(t,
+		map[string]any{"command": "initialize"},
+		map[string]any{"command": "launch", "arguments": map[string]any{"program": filename}},
+		map[string]any{"command": "setBreakpoints", "arguments": map[string]any{
+			"source":      map[string]any{"path": filename},
+			"breakpoints": []map[string]any{{"line": 5}},
+		}},
+		map[string]any{"command": "configurationDone"},
+		map[string]any{"command": "stackTrace", "arguments": map[string]any{"threadId": 1}},
+		map[string]any{"command": "variables", "arguments": map[string]any{"variablesReference": 1}},
+		map[string]any{"command": "stepOut", "arguments": map[string]any{"threadId": 1}},
+		map[string]any{"command": "continue", "arguments": map[string]any{"threadId": 1}},
+		map[string]any{"command": "disconnect"},
+	))
+
+	assert.Equal(t, []string{
+		"initialize",
+		"launch", "initialized event",
+		"setBreakpoints",
+		"configurationDone", "stopped event",
+		"stackTrace",
+		"variables",
+		"stepOut", "output event", "stopped event",
+		"continue", "exited event", "terminated event",
+		"disconnect",
+	}, kinds(messages))
+
+	breakpoints := messages[3]["body"].(map[string]any)["breakpoints"].([]any)
+	assert.Equal(t, map[string]any{"verified": true, "line": float64(5)}, breakpoints[0])
+
+	assert.Equal(t, "breakpoint", messages[5]["body"].(map[string]any)["reason"])
+
+	frames := messages[6]["body"].(map[string]any)["stackFrames"].([]any)
+	assert.Equal(t, 2, len(frames))
+	assert.Equal(t, "F", frames[0].(map[string]any)["name"])
+	assert.Equal(t, float64(5), frames[0].(map[string]any)["line"])
+	assert.Equal(t, "main", frames[1].(map[string]any)["name"])
+	assert.Equal(t, float64(2), frames[1].(map[string]any)["line"])
+
+	variables := messages[7]["body"].(map[string]any)["variables"].([]any)
+	assert.Equal(t, "2", variables[0].(map[string]any)["value"])
+
+	assert.Equal(t, "2", messages[9]["body"].(map[string]any)["output"])
+	assert.Equal(t, "step", messages[10]["body"].(map[string]any)["reason"])
+}
+
+func TestServePause(t *testing.T) {
+	// label F, jump F
+	filename := writeProgram(t, "TFFFT\nTFTFT\n")
+
+	messages := serve(t, requests(t,
+		map[string]any{"command": "initialize"},
+		map[string]any{"command": "launch", "arguments": map[string]any{"program": filename}},
+		map[string]any{"command": "configurationDone"},
+		map[string]any{"command": "pause", "arguments": map[string]any{"threadId": 1}},
+		map[string]any{"command": "stackTrace", "arguments": map[string]any{"threadId": 1}},
+		map[string]any{"command": "continue", "arguments": map[string]any{"threadId": 1}},
+		map[string]any{"command": "disconnect"},
+	))
+
+	assert.Equal(t, []string{
+		"initialize",
+		"launch", "initialized event",
+		"configurationDone",
+		"pause", "stopped event",
+		"stackTrace",
+		"continue",
+		"disconnect",
+	}, kinds(messages))
+	assert.Equal(t, "pause", messages[5]["body"].(map[string]any)["reason"])
+	assert.Equal(t, true, messages[4]["success"])
+}
+
+func TestServeRuntimeError(t *testing.T) {
+	// putn with an empty stack
+	filename := writeProgram(t, "LTFL\n")
+
+	messages := serve(t, requests(t,
+		map[string]any{"command": "initialize"},
+		map[string]any{"command": "launch", "arguments": map[string]any{"program": filename}},
+		map[string]any{"command": "configurationDone"},
+		map[string]any{"command": "continue", "arguments": map[string]any{"threadId": 1}},
+	))
+
+	assert.Equal(t, []string{
+		"initialize",
+		"launch", "initialized event",
+		"configurationDone", "output event", "stopped event",
+		"continue", "exited event", "terminated event",
+	}, kinds(messages))
+	assert.Equal(t, "stderr", messages[4]["body"].(map[string]any)["category"])
+	assert.Equal(t, "exception", messages[5]["body"].(map[string]any)["reason"])
+	assert.Equal(t, float64(1), messages[7]["body"].(map[string]any)["exitCode"])
+}
+
+func TestServeLaunchError(t *testing.T) {
+	messages := serve(t, requests(t,
+		map[string]any{"command": "launch", "arguments": map[string]any{"program": "not_found.fflt"}},
+	))
+
+	assert.Equal(t, 1, len(messages))
+	assert.Equal(t, false, messages[0]["success"])
+	assert.Equal(t, "not_found.fflt can not read", messages[0]["message"])
+}
+
+func TestServeBreakpointLine(t *testing.T) {
+	// push 2 with its command split across lines 1 and 2, then putn
+	filename := writeProgram(t, "F\nFFLFT\nLTFL\n")
+
+	messages := serve(t, requests(t,
+		map[string]any{"command": "initialize"},
+		map[string]any{"command": "launch", "arguments": map[string]any{"program": filename, "stopOnEntry": true}},
+		map[string]any{"command": "setBreakpoints", "arguments": map[string]any{
+			"source":      map[string]any{"path": filename},
+			"breakpoints": []map[string]any{{"line": 1}},
+		}},
+		map[string]any{"command": "configurationDone"},
+		map[string]any{"command": "stackTrace", "arguments": map[string]any{"threadId": 1}},
+		map[string]any{"command": "disconnect"},
+	))
+
+	breakpoints := messages[3]["body"].(map[string]any)["breakpoints"].([]any)
+	frames := messages[6]["body"].(map[string]any)["stackFrames"].([]any)
+	assert.Equal(t, map[string]any{"verified": true, "line": float64(1)}, breakpoints[0])
+	assert.Equal(t, float64(1), frames[0].(map[string]any)["line"])
+}
@@ -0,0 +1,170 @@
+package executor
+
+import (
+	"math"
+	"math/big"
+)
+
+type ArithmeticMode string
+
+const (
+	ArithmeticWrap = ArithmeticMode("wrap")
+	ArithmeticTrap = ArithmeticMode("trap")
+	ArithmeticBig  = ArithmeticMode("big")
+)
+
+// mode returns the arithmetic mode, which is wrap unless it is set.
+func (executor *Executor) mode() ArithmeticMode {
+	if executor.Arithmetic == "" {
+		return ArithmeticWrap
+	}
+	return executor.Arithmetic
+}
+
+// wraps reports whether an operation on lhs and rhs is plain int arithmetic,
+// which is the fast path taken unless the mode checks or avoids overflow.
+func (executor *Executor) wraps(lhs, rhs Number) bool {
+	return lhs.big == "" && rhs.big == "" && executor.Arithmetic != ArithmeticTrap && executor.Arithmetic != ArithmeticBig
+}
+
+func (executor *Executor) fit(n Number) (Number, bool) {
+	if n.IsInt() {
+		return n, true
+	}
+
+	switch executor.Arithmetic {
+	case ArithmeticBig:
+		return n, true
+	case ArithmeticTrap:
+		return n, false
+	default:
+		return NewNumber(n.Int()), true
+	}
+}
+
+func (executor *Executor) add(lhs, rhs Number) (Number, bool) {
+	if executor.wraps(lhs, rhs) {
+		return NewNumber(lhs.small + rhs.small), true
+	}
+
+	switch executor.Arithmetic {
+	case ArithmeticBig:
+		if lhs.IsInt() && rhs.IsInt() {
+			if n, ok := addInt(lhs.small, rhs.small); ok {
+				return NewNumber(n), true
+			}
+		}
+		return NewBigNumber(new(big.Int).Add(lhs.Big(), rhs.Big())), true
+	case ArithmeticTrap:
+		n, ok := addInt(lhs.Int(), rhs.Int())
+		return NewNumber(n), ok
+	default:
+		return NewNumber(lhs.Int() + rhs.Int()), true
+	}
+}
+
+func (executor *Executor) subtract(lhs, rhs Number) (Number, bool) {
+	if executor.wraps(lhs, rhs) {
+		return NewNumber(lhs.small - rhs.small), true
+	}
+
+	switch executor.Arithmetic {
+	case ArithmeticBig:
+		if lhs.IsInt() && rhs.IsInt() {
+			if n, ok := subtractInt(lhs.small, rhs.small); ok {
+				return NewNumber(n), true
+			}
+		}
+		return NewBigNumber(new(big.Int).Sub(lhs.Big(), rhs.Big())), true
+	case ArithmeticTrap:
+		n, ok := subtractInt(lhs.Int(), rhs.Int())
+		return NewNumber(n), ok
+	default:
+		return NewNumber(lhs.Int() - rhs.Int()), true
+	}
+}
+
+func (executor *Executor) multiply(lhs, rhs Number) (Number, bool) {
+	if executor.wraps(lhs, rhs) {
+		return NewNumber(lhs.small * rhs.small), true
+	}
+
+	switch executor.Arithmetic {
+	case ArithmeticBig:
+		if lhs.IsInt() && rhs.IsInt() {
+			if n, ok := multiplyInt(lhs.small, rhs.small); ok {
+				return NewNumber(n), true
+			}
+		}
+		return NewBigNumber(new(big.Int).Mul(lhs.Big(), rhs.Big())), true
+	case ArithmeticTrap:
+		n, ok := multiplyInt(lhs.Int(), rhs.Int())
+		return NewNumber(n), ok
+	default:
+		return NewNumber(lhs.Int() * rhs.Int()), true
+	}
+}
+
+func (executor *Executor) divide(lhs, rhs Number) (Number, bool) {
+	if executor.wraps(lhs, rhs) {
+		return NewNumber(lhs.small / rhs.small), true
+	}
+
+	switch executor.Arithmetic {
+	case ArithmeticBig:
+		if lhs.IsInt() && rhs.IsInt() {
+			if n, ok := divideInt(lhs.small, rhs.small); ok {
+				return NewNumber(n), true
+			}
+		}
+		return NewBigNumber(new(big.Int).Quo(lhs.Big(), rhs.Big())), true
+	case ArithmeticTrap:
+		n, ok := divideInt(lhs.Int(), rhs.Int())
+		return NewNumber(n), ok
+	default:
+		return NewNumber(lhs.Int() / rhs.Int()), true
+	}
+}
+
+func (executor *Executor) modulo(lhs, rhs Number) Number {
+	if executor.wraps(lhs, rhs) {
+		return NewNumber(lhs.small % rhs.small)
+	}
+
+	if executor.Arithmetic == ArithmeticBig && !(lhs.IsInt() && rhs.IsInt()) {
+		return NewBigNumber(new(big.Int).Rem(lhs.Big(), rhs.Big()))
+	}
+	return NewNumber(lhs.Int() % rhs.Int())
+}
+
+func addInt(a, b int) (int, bool) {
+	n := a + b
+	if (a > 0 && b > 0 && n < 0) || (a < 0 && b < 0 && n >= 0) {
+		return n, false
+	}
+	return n, true
+}
+
+func subtractInt(a, b int) (int, bool) {
+	n := a - b
+	if (a >= 0 && b < 0 && n < 0) || (a < 0 && b > 0 && n >= 0) {
+		return n, false
+	}
+	return n, true
+}
+
+func multiplyInt(a, b int) (int, bool) {
+	n := a * b
+	if a != 0 && (n/a != b || (a == -1 && b == math.MinInt)) {
+		return n, false
+	}
+	return n, true
+}
+
+func divideInt(a, b int) (int, bool) {
+	n := a / b
+	if a == math.MinInt && b == -1 {
+		return n, false
+	}
+	return n, true
+}
@@ -1,26 +1,36 @@
 package executor
 
 import (
+	"errors"
 	"fmt"
-	"log"
+	"io"
 	"os"
 	"path/filepath"
 	"sort"
-	"strconv"
 	"strings"
+	"sync/atomic"
 
-	mapset "github.com/deckarep/golang-set/v2"
 	"github.com/olekukonko/tablewriter"
-	"github.com/peterh/liner"
 )
 
 var (
 	commands = []string{
 		"s", "step",
+		"n", "next",
+		"finish",
+		"until",
 		"c", "continue",
+		"rs", "reverse-step",
+		"rc", "reverse-continue",
 		"b", "break",
 		"d", "delete",
+		"ignore",
+		"watch", "rwatch", "awatch", "unwatch",
+		"set stack", "set heap", "set pc", "jump",
+		"push", "pop", "input",
+		"restart", "restart reload",
 		"ib", "info breakpoints",
+		"iw", "info watchpoints",
 		"is", "info stack",
 		"ih", "info heap",
 		"iv", "info vm",
@@ -30,10 +40,35 @@ var (
 	}
 
 	help = `step, s --- Step instruction
+next, n --- Step instruction, stepping over subroutine calls
+finish --- Run until the current subroutine returns
+until [N] --- Run until Nth instruction, label or FILE:LINE is reached
 continue, c --- Disassenble instructions
+reverse-step, rs --- Step back one instruction
+reverse-continue, rc --- Run backward to the previous breakpoint
 break [N], b [N] --- Set breakpoint at Nth instruction
-delete [N], d [N] --- Delete breakpoint at Nth instruction
+break [LABEL], b [LABEL] --- Set breakpoint at label
+break [FILE:LINE], b [FILE:LINE] --- Set breakpoint at first instruction on or after line
+break [N] if [EXPR] --- Set breakpoint which stops only when EXPR is true
+    EXPR can use top, stack[N], depth, calldepth, heap[N], pc,
+    ==, !=, <, <=, >, >=, !, && and ||
+delete [N], d [N] --- Delete breakpoint at Nth instruction, label or FILE:LINE
+ignore [N] [COUNT] --- Ignore next COUNT hits of breakpoint at Nth instruction, label or FILE:LINE
+watch heap[ADDR] --- Stop after an instruction writes heap[ADDR]
+rwatch heap[ADDR] --- Stop after an instruction reads heap[ADDR]
+awatch heap[ADDR] --- Stop after an instruction reads or writes heap[ADDR]
+unwatch heap[ADDR] --- Delete watchpoint of heap[ADDR]
+set stack [N] [VALUE] --- Set Nth item from the top of the stack to VALUE
+set heap [ADDR] [VALUE] --- Set heap[ADDR] to VALUE
+set pc [N] --- Move to Nth instruction
+jump [LABEL] --- Move to label, Nth instruction or FILE:LINE
+push [VALUE] --- Push VALUE onto the stack
+pop --- Pop the top of the stack
+input "TEXT" --- Queue TEXT as program input, e.g. input "42\n"
+restart --- Run the program again from the beginning
+restart reload --- Reload the source file and run it again from the beginning
 info breakpoints, ib --- Show breakpoints
+info watchpoints, iw --- Show watchpoints
 info stack, is --- Show stack information
 info heap, ih --- Show heap information
 info vm, iv --- Show VM information
@@ -53,154 +88,269 @@ const (
 
 type DebuggerState string
 
+type StopReason string
+
+const (
+	StopReasonEntry      = StopReason("entry")
+	StopReasonStep       = StopReason("step")
+	StopReasonBreakpoint = StopReason("breakpoint")
+	StopReasonWatchpoint = StopReason("data breakpoint")
+	StopReasonPause      = StopReason("pause")
+	StopReasonException  = StopReason("exception")
+	StopReasonExited     = StopReason("exited")
+)
+
+// Debugger runs a program interactively. Commands are read from Commands, or
+// from a line editor on the terminal when it is nil, and messages are written
+// to Stdout and Stderr. The program reads its input from Input, or line by
+// line from Commands when it is nil.
 type Debugger struct {
-	executor               *Executor
-	stackTableWriter       *tablewriter.Table
-	heapTableWriter        *tablewriter.Table
-	labelMapTableWriter    *tablewriter.Table
-	callStackTableWriter   *tablewriter.Table
-	breakPoints            mapset.Set[int]
-	breakPointsTableWriter *tablewriter.Table
-	state                  DebuggerState
-	stdin                  *liner.State
-	stdout                 string
-	lastoccurredError      error
+	executor          *Executor
+	breakPoints       map[int]*breakpoint
+	watchPoints       map[Number]*watchpoint
+	watchHits         []HeapAccess
+	stopCondition     func() bool
+	stopReason        StopReason
+	paused            atomic.Bool
+	state             DebuggerState
+	programOutput     string
+	lastoccurredError error
+	history           []*historyEntry
+	HistorySize       int
+	HistoryPath       string
+	Commands          CommandReader
+	Input             io.Reader
+	Reload            func() ([]Instruction, map[string]int, error)
+	Stdout            io.Writer
+	Stderr            io.Writer
 }
 
 func NewDebugger(executor *Executor) *Debugger {
-	stackTableWriter := tablewriter.NewWriter(os.Stdout)
-	stackTableWriter.SetHeader([]string{"Value"})
-
-	heapTableWriter := tablewriter.NewWriter(os.Stdout)
-	heapTableWriter.SetHeader([]string{"Address", "Value"})
-
-	labelMapTableWriter := tablewriter.NewWriter(os.Stdout)
-	labelMapTableWriter.SetHeader([]string{"Label", "Instruction index"})
+	debugger := &Debugger{
+		executor:    executor,
+		breakPoints: map[int]*breakpoint{0: {location: "0"}},
+		watchPoints: map[Number]*watchpoint{},
+		state:       DebuggerStateInterrupt,
+		HistorySize: DefaultHistorySize,
+		HistoryPath: filepath.Join(os.TempDir(), ".fflt_debug_history"),
+		Stdout:      os.Stdout,
+		Stderr:      os.Stderr,
+	}
 
-	callStackTableWriter := tablewriter.NewWriter(os.Stdout)
-	callStackTableWriter.SetHeader([]string{"Instruction index"})
+	debugger.executor.OnHeapAccess = debugger.onHeapAccess
+	debugger.executor.onInput = debugger.recordInput
 
-	breakPoints := mapset.NewSet[int]()
-	breakPoints.Add(0)
+	debugger.executor.Input = &debuggerInput{debugger: debugger}
+	debugger.executor.Output = &debuggerOutput{debugger: debugger}
 
-	breakPointsTableWriter := tablewriter.NewWriter(os.Stdout)
-	breakPointsTableWriter.SetHeader([]string{"Breakpoints"})
+	return debugger
+}
 
-	debugger := &Debugger{
-		executor:               executor,
-		stackTableWriter:       stackTableWriter,
-		heapTableWriter:        heapTableWriter,
-		labelMapTableWriter:    labelMapTableWriter,
-		callStackTableWriter:   callStackTableWriter,
-		breakPoints:            breakPoints,
-		breakPointsTableWriter: breakPointsTableWriter,
-		state:                  DebuggerStateInterrupt,
-		stdin:                  liner.NewLiner(),
-	}
+type debuggerInput struct {
+	debugger *Debugger
+	buffer   []byte
+}
 
-	debugger.executor.Input = func() string {
-		str, _ := debugger.stdin.Prompt("")
-		return str
+func (i *debuggerInput) Read(p []byte) (int, error) {
+	if i.debugger.Input != nil {
+		return i.debugger.Input.Read(p)
 	}
 
-	debugger.executor.Output = func(value string) {
-		debugger.stdout += value
-		if debugger.state == DebuggerStateContinue {
-			fmt.Printf(value)
+	if len(i.buffer) == 0 {
+		str, err := i.debugger.Commands.Prompt("")
+		if err != nil {
+			return 0, io.EOF
 		}
+		i.buffer = []byte(str + "\n")
 	}
 
-	return debugger
+	n := copy(p, i.buffer)
+	i.buffer = i.buffer[n:]
+	return n, nil
 }
 
-func (d *Debugger) Run() error {
-	defer func() {
-		d.stdin.Close()
-	}()
-
-	d.stdin.SetCtrlCAborts(true)
-	d.stdin.SetCompleter(func(line string) (c []string) {
-		for _, command := range commands {
-			if strings.HasPrefix(command, line) {
-				c = append(c, command)
-			}
-		}
-		return
-	})
+type debuggerOutput struct {
+	debugger *Debugger
+}
 
-	historyPath := filepath.Join(os.TempDir(), ".fflt_debug_history")
+func (o *debuggerOutput) Write(p []byte) (int, error) {
+	o.debugger.programOutput += string(p)
+	if o.debugger.state == DebuggerStateContinue {
+		fmt.Fprint(o.debugger.Stdout, string(p))
+	}
+	return len(p), nil
+}
 
-	if f, err := os.Open(historyPath); err == nil {
-		d.stdin.ReadHistory(f)
-		f.Close()
+func (d *Debugger) Run() error {
+	if d.Commands == nil {
+		editor := newLineEditor(d.HistoryPath)
+		d.Commands = editor
+		defer func() {
+			editor.Close()
+			d.Commands = nil
+		}()
 	}
 
-	d.executor.heap = map[int]int{}
+	d.executor.heap = newHeap()
 	d.executor.programCounter = 0
+	d.executor.steps = 0
 
-	for d.executor.programCounter = 0; d.executor.programCounter < len(d.executor.Instructions); {
-		if d.state == DebuggerStateExit {
-			break
+	for d.state != DebuggerStateExit {
+		if d.resume() == StopReasonExited {
+			d.showExited()
+		} else {
+			d.showDebuggerStatus()
+			d.showStack()
 		}
-		if d.breakPoints.Contains(d.executor.programCounter) {
-			d.state = DebuggerStateInterrupt
+
+		if err := d.handleCommand(); err != nil {
+			return err
 		}
+	}
 
-		if d.state == DebuggerStateInterrupt || d.state == DebuggerStateError {
-			d.showDebuggerStatus()
-			d.showStack()
+	return nil
+}
 
-			if err := d.handleCommand(); err != nil {
-				return err
-			}
-		} else {
-			d.executeInstruction()
+// resume executes instructions while the debugger is continuing and reports
+// why it stopped.
+func (d *Debugger) resume() StopReason {
+	defer d.paused.Store(false)
+
+	for d.executor.programCounter < len(d.executor.Instructions) {
+		if d.state == DebuggerStateContinue && d.paused.Load() {
+			d.state = DebuggerStateInterrupt
+			d.stopReason = StopReasonPause
 		}
+		if d.state == DebuggerStateContinue && d.hitBreakpoint(d.executor.programCounter) {
+			d.state = DebuggerStateInterrupt
+			d.stopReason = StopReasonBreakpoint
+		}
+		if d.state == DebuggerStateContinue && d.stopCondition != nil && d.stopCondition() {
+			d.state = DebuggerStateInterrupt
+			d.stopReason = StopReasonStep
+		}
+
+		if d.state != DebuggerStateContinue {
+			d.stopCondition = nil
+			return d.stopReason
+		}
+		d.executeInstruction()
 	}
 
-	if f, err := os.Create(historyPath); err != nil {
-		log.Print("Error writing history file: ", err)
-	} else {
-		d.stdin.WriteHistory(f)
-		f.Close()
+	return StopReasonExited
+}
+
+// Start runs the program from the beginning until it stops, or stops before
+// the first instruction when stopOnEntry is set. Unlike Continue, a
+// breakpoint at the first instruction is hit.
+func (d *Debugger) Start(stopOnEntry bool) StopReason {
+	d.executor.heap = newHeap()
+	d.executor.programCounter = 0
+	d.executor.steps = 0
+
+	if stopOnEntry && len(d.executor.Instructions) > 0 {
+		d.state = DebuggerStateInterrupt
+		return StopReasonEntry
 	}
 
-	return nil
+	d.state = DebuggerStateContinue
+	return d.resume()
+}
+
+func (d *Debugger) Continue() StopReason {
+	d.runUntil(nil)
+	return d.resume()
+}
+
+func (d *Debugger) Step() StopReason {
+	d.executeInstruction()
+	return d.resume()
+}
+
+func (d *Debugger) Next() StopReason {
+	d.next()
+	return d.resume()
+}
+
+func (d *Debugger) Finish() StopReason {
+	if !d.finish() {
+		return StopReasonStep
+	}
+	return d.resume()
+}
+
+// Pause makes the program stop before the next instruction when it is
+// running. Unlike the other methods, it may be called while another goroutine
+// runs the program.
+func (d *Debugger) Pause() {
+	d.paused.Store(true)
+}
+
+func (d *Debugger) Err() error {
+	return d.lastoccurredError
 }
 
 func (d *Debugger) handleCommand() error {
 	for true {
-		if command, err := d.stdin.Prompt("> "); err == nil {
+		if command, err := d.Commands.Prompt("> "); err == nil {
 			switch command {
 			case "s", "step":
-				d.stdin.AppendHistory(command)
+				d.Commands.AppendHistory(command)
 				d.executeInstruction()
 				return nil
-			case "c", "continue":
-				d.stdin.AppendHistory(command)
-				d.executeInstruction()
-				if d.state == DebuggerStateInterrupt {
-					d.state = DebuggerStateContinue
+			case "n", "next":
+				d.Commands.AppendHistory(command)
+				d.next()
+				return nil
+			case "finish":
+				d.Commands.AppendHistory(command)
+				if d.finish() {
+					return nil
 				}
+			case "c", "continue":
+				d.Commands.AppendHistory(command)
+				d.runUntil(nil)
+				return nil
+			case "rs", "reverse-step":
+				d.Commands.AppendHistory(command)
+				d.reverseStep()
+				return nil
+			case "rc", "reverse-continue":
+				d.Commands.AppendHistory(command)
+				d.reverseContinue()
 				return nil
 			case "is", "info stack":
-				d.stdin.AppendHistory(command)
+				d.Commands.AppendHistory(command)
 				d.showStack()
 			case "ih", "info heap":
-				d.stdin.AppendHistory(command)
+				d.Commands.AppendHistory(command)
 				d.showHeap()
 			case "iv", "info vm":
-				d.stdin.AppendHistory(command)
+				d.Commands.AppendHistory(command)
 				d.showVM()
 			case "ii", "info instructions":
-				d.stdin.AppendHistory(command)
+				d.Commands.AppendHistory(command)
 				d.showInstructions()
 			case "ib", "info breakpoints":
-				d.stdin.AppendHistory(command)
+				d.Commands.AppendHistory(command)
 				d.showBreakpoints()
+			case "iw", "info watchpoints":
+				d.Commands.AppendHistory(command)
+				d.showWatchpoints()
 			case "h", "help":
-				d.stdin.AppendHistory(command)
+				d.Commands.AppendHistory(command)
 				d.showHelp()
+			case "pop":
+				d.Commands.AppendHistory(command)
+				d.pop()
+			case "restart":
+				d.Commands.AppendHistory(command)
+				d.restart(false)
+				return nil
+			case "restart reload":
+				d.Commands.AppendHistory(command)
+				d.restart(true)
+				return nil
 			case "exit":
 				d.state = DebuggerStateExit
 				return nil
@@ -210,8 +360,13 @@ func (d *Debugger) handleCommand() error {
 					d.showUnknownCommand(command)
 					break
 				}
-				d.handleCommandWithArg(split[0], split[1])
+				if d.handleCommandWithArg(split[0], split[1]) {
+					return nil
+				}
 			}
+		} else if errors.Is(err, io.EOF) {
+			d.state = DebuggerStateExit
+			return nil
 		} else {
 			return err
 		}
@@ -220,52 +375,124 @@ func (d *Debugger) handleCommand() error {
 	return nil
 }
 
-func (d *Debugger) handleCommandWithArg(name, arg string) error {
-	n, err := strconv.Atoi(arg)
-	if err != nil {
-		fmt.Printf("Invalid command arguments: \"%s %s\", Try \"help\"\n", name, arg)
-		return nil
-	}
-
+// handleCommandWithArg reports whether the command resumes execution.
+func (d *Debugger) handleCommandWithArg(name, arg string) bool {
 	switch name {
 	case "b", "break":
-		d.breakPoints.Add(n)
+		d.setBreakpoint(arg)
 	case "d", "delete":
-		d.breakPoints.Remove(n)
+		d.deleteBreakpoint(arg)
+	case "ignore":
+		d.ignoreBreakpoint(arg)
+	case "until":
+		n, ok := d.resolveLocation(arg)
+		if !ok {
+			return false
+		}
+		d.runUntil(func() bool {
+			return d.executor.programCounter == n
+		})
+		return true
+	case "watch", "rwatch", "awatch":
+		d.setWatchpoint(name, arg)
+	case "unwatch":
+		d.deleteWatchpoint(arg)
+	case "set":
+		d.set(arg)
+	case "push":
+		d.push(arg)
+	case "jump":
+		d.jump(arg)
+	case "input":
+		d.queueInput(arg)
 	default:
 		d.showUnknownCommand(name)
 	}
-	return nil
+	return false
+}
+
+func (d *Debugger) runUntil(stop func() bool) {
+	if d.state == DebuggerStateInterrupt {
+		d.state = DebuggerStateContinue
+		d.stopCondition = stop
+	}
+	d.executeInstruction()
+}
+
+func (d *Debugger) next() {
+	if _, ok := d.executor.currentInstruction().(CallSubroutine); !ok {
+		d.executeInstruction()
+		return
+	}
+
+	depth := len(d.executor.callStack)
+	d.runUntil(func() bool {
+		return len(d.executor.callStack) <= depth
+	})
+}
+
+// finish reports whether execution is resumed.
+func (d *Debugger) finish() bool {
+	depth := len(d.executor.callStack)
+	if depth == 0 {
+		fmt.Fprintf(d.Stdout, "\"finish\" not meaningful in the outermost frame.\n")
+		return false
+	}
+
+	d.runUntil(func() bool {
+		return len(d.executor.callStack) < depth
+	})
+	return true
 }
 
 func (d *Debugger) executeInstruction() {
+	if d.executor.programCounter >= len(d.executor.Instructions) {
+		fmt.Fprintf(d.Stdout, "The program is not being run.\n")
+		return
+	}
 	if d.state == DebuggerStateError {
-		fmt.Fprintf(os.Stderr, "Runtime error occured: (%s)\n", d.lastoccurredError.Error())
+		fmt.Fprintf(d.Stderr, "Runtime error occured: (%s)\n", d.lastoccurredError.Error())
 		return
 	}
 
-	err := d.executor.Instructions[d.executor.programCounter].Execute(d.executor)
+	d.recordHistory(d.stackChange(d.executor.Instructions[d.executor.programCounter]))
+	d.watchHits = nil
+	d.stopReason = StopReasonStep
+	index := d.executor.programCounter
+	err := d.executor.execute()
+	d.executor.Flush()
 	if err != nil {
-		fmt.Fprintln(os.Stderr, err.Error())
+		fmt.Fprintln(d.Stderr, err.Error())
 		d.state = DebuggerStateError
+		d.stopReason = StopReasonException
 		d.lastoccurredError = err
 	} else {
 		d.executor.programCounter++
+		if d.reportWatchpoints(fmt.Sprintf("%04d %s", index, d.executor.Instructions[index].Disassenble())) {
+			d.state = DebuggerStateInterrupt
+			d.stopReason = StopReasonWatchpoint
+		}
 	}
 }
 
 func (d *Debugger) showDebuggerStatus() {
 	inst := d.executor.Instructions[d.executor.programCounter]
-	fmt.Printf("\n")
-	fmt.Printf("Program counter: %d\n", d.executor.programCounter)
-	fmt.Printf("Current instruction: " + inst.Disassenble() + "\n")
-	fmt.Printf("Output: " + d.stdout + "\n")
+	fmt.Fprintf(d.Stdout, "\n")
+	fmt.Fprintf(d.Stdout, "Program counter: %d\n", d.executor.programCounter)
+	fmt.Fprintf(d.Stdout, "Current instruction: %s\n", inst.Disassenble())
+	fmt.Fprintf(d.Stdout, "Output: %s\n", d.programOutput)
+}
+
+func (d *Debugger) showExited() {
+	fmt.Fprintf(d.Stdout, "\n")
+	fmt.Fprintf(d.Stdout, "Program exited\n")
+	fmt.Fprintf(d.Stdout, "Output: %s\n", d.programOutput)
 }
 
 func (d *Debugger) showVM() {
-	fmt.Printf("\n")
-	fmt.Printf("Filename: %s\n", d.executor.Filename)
-	fmt.Printf("Program counter: %d\n", d.executor.programCounter)
+	fmt.Fprintf(d.Stdout, "\n")
+	fmt.Fprintf(d.Stdout, "Filename: %s\n", d.executor.Filename)
+	fmt.Fprintf(d.Stdout, "Program counter: %d\n", d.executor.programCounter)
 	d.showLabelMap()
 	d.showCallStack()
 	d.showStack()
@@ -273,95 +500,111 @@ func (d *Debugger) showVM() {
 }
 
 func (d *Debugger) showStack() {
-	d.stackTableWriter.ClearRows()
+	table := d.newTable("Value")
 	for i := len(d.executor.stack) - 1; i >= 0; i-- {
-		d.stackTableWriter.Append([]string{fmt.Sprintf("%d", d.executor.stack[i])})
+		table.Append([]string{d.executor.stack[i].String()})
 	}
 
-	fmt.Printf("\n")
-	outputHeader("Stack")
-	d.stackTableWriter.Render()
+	fmt.Fprintf(d.Stdout, "\n")
+	d.outputHeader("Stack")
+	table.Render()
 }
 
 func (d *Debugger) showHeap() {
-	d.heapTableWriter.ClearRows()
-	keys := []int{}
-	for k := range d.executor.heap {
-		keys = append(keys, k)
-	}
-	sort.Ints(keys)
-	for _, key := range keys {
-		d.heapTableWriter.Append([]string{
-			fmt.Sprintf("%d", key),
-			fmt.Sprintf("%d", d.executor.heap[key]),
+	table := d.newTable("Address", "Value")
+	for _, cell := range d.executor.heap.cells() {
+		table.Append([]string{
+			cell.Address.String(),
+			cell.Value.String(),
 		})
 	}
 
-	fmt.Printf("\n")
-	outputHeader("Heap")
-	d.heapTableWriter.Render()
+	fmt.Fprintf(d.Stdout, "\n")
+	d.outputHeader("Heap")
+	table.Render()
 }
 
 func (d *Debugger) showLabelMap() {
-	d.labelMapTableWriter.ClearRows()
+	table := d.newTable("Label", "Instruction index")
 	keys := []string{}
 	for k := range d.executor.LabelMap {
 		keys = append(keys, k)
 	}
 	sort.Strings(keys)
 	for _, key := range keys {
-		d.labelMapTableWriter.Append([]string{
+		table.Append([]string{
 			key,
 			fmt.Sprintf("%d", d.executor.LabelMap[key]),
 		})
 	}
-	fmt.Printf("\n")
-	outputHeader("Label map")
-	d.labelMapTableWriter.Render()
+	fmt.Fprintf(d.Stdout, "\n")
+	d.outputHeader("Label map")
+	table.Render()
 }
 
 func (d *Debugger) showCallStack() {
-	d.callStackTableWriter.ClearRows()
+	table := d.newTable("Instruction index")
 	for i := len(d.executor.callStack) - 1; i >= 0; i-- {
-		d.callStackTableWriter.Append([]string{fmt.Sprintf("%d", d.executor.callStack[i])})
+		table.Append([]string{fmt.Sprintf("%d", d.executor.callStack[i])})
 	}
-	fmt.Printf("\n")
-	outputHeader("Callstack")
-	d.callStackTableWriter.Render()
+	fmt.Fprintf(d.Stdout, "\n")
+	d.outputHeader("Callstack")
+	table.Render()
 }
 
 func (d *Debugger) showInstructions() {
-	fmt.Printf("\n")
-	outputHeader("Instructions")
-	fmt.Printf("\n")
+	fmt.Fprintf(d.Stdout, "\n")
+	d.outputHeader("Instructions")
+	fmt.Fprintf(d.Stdout, "\n")
 	for i, ins := range d.executor.Instructions {
 		if d.executor.programCounter == i {
-			fmt.Printf("-> %04d %s\n", i, ins.Disassenble())
+			fmt.Fprintf(d.Stdout, "-> %04d %s\n", i, ins.Disassenble())
 		} else {
-			fmt.Printf("   %04d %s\n", i, ins.Disassenble())
+			fmt.Fprintf(d.Stdout, "   %04d %s\n", i, ins.Disassenble())
 		}
 	}
 }
 
 func (d *Debugger) showBreakpoints() {
-	d.breakPointsTableWriter.ClearRows()
-	for b := range d.breakPoints.Iter() {
-		d.breakPointsTableWriter.Append([]string{fmt.Sprintf("%d", b)})
+	table := d.newTable("Breakpoints", "Condition", "Hits", "Ignore")
+	indexes := []int{}
+	for index := range d.breakPoints {
+		indexes = append(indexes, index)
 	}
-	fmt.Printf("\n")
-	d.breakPointsTableWriter.Render()
+	sort.Ints(indexes)
+	for _, index := range indexes {
+		b := d.breakPoints[index]
+		table.Append([]string{
+			fmt.Sprintf("%d", index),
+			b.conditionSource,
+			fmt.Sprintf("%d", b.hitCount),
+			fmt.Sprintf("%d", b.ignoreCount),
+		})
+	}
+	fmt.Fprintf(d.Stdout, "\n")
+	table.Render()
 }
 
 func (d *Debugger) showHelp() {
-	fmt.Print(help)
+	fmt.Fprint(d.Stdout, help)
 }
 
 func (d *Debugger) showUnknownCommand(command string) {
-	fmt.Printf("Unknown command: \"%s\", Try \"help\"\n", command)
+	fmt.Fprintf(d.Stdout, "Unknown command: \"%s\", Try \"help\"\n", command)
+}
+
+func (d *Debugger) showInvalidArguments(name, arg string) {
+	fmt.Fprintf(d.Stdout, "Invalid command arguments: \"%s %s\", Try \"help\"\n", name, arg)
 }
 
-func outputHeader(title string) {
+func (d *Debugger) outputHeader(title string) {
 	str := "-- " + title + " "
 	remaining := HeaderLength - len(str)
-	fmt.Print(str + strings.Repeat("-", remaining) + "\n")
+	fmt.Fprint(d.Stdout, str+strings.Repeat("-", remaining)+"\n")
+}
+
+func (d *Debugger) newTable(header ...string) *tablewriter.Table {
+	table := tablewriter.NewWriter(d.Stdout)
+	table.SetHeader(header)
+	return table
 }
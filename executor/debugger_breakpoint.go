@@ -0,0 +1,218 @@
+package executor
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// breakpoint keeps the location it was set at, e.g. a label or FILE:LINE, so
+// that it can be moved when the program is reloaded.
+type breakpoint struct {
+	location        string
+	condition       expression
+	conditionSource string
+	hitCount        int
+	ignoreCount     int
+}
+
+func (d *Debugger) setBreakpoint(arg string) {
+	location, conditionSource, hasCondition := strings.Cut(arg, " if ")
+	n, ok := d.resolveLocation(location)
+	if !ok {
+		return
+	}
+
+	if !hasCondition {
+		conditionSource = ""
+	}
+	if err := d.setBreakpointAt(n, strings.TrimSpace(location), conditionSource); err != nil {
+		fmt.Fprintf(d.Stdout, "Invalid condition: \"%s\" (%s)\n", conditionSource, err.Error())
+		return
+	}
+	fmt.Fprintf(d.Stdout, "Breakpoint at %d\n", n)
+}
+
+// SetBreakpoint sets a breakpoint at the index. An empty condition means the
+// breakpoint always stops.
+func (d *Debugger) SetBreakpoint(index int, condition string) error {
+	return d.setBreakpointAt(index, strconv.Itoa(index), condition)
+}
+
+func (d *Debugger) setBreakpointAt(index int, location string, condition string) error {
+	b := &breakpoint{location: location}
+	if strings.TrimSpace(condition) != "" {
+		expr, err := parseExpression(condition)
+		if err != nil {
+			return err
+		}
+		b.condition = expr
+		b.conditionSource = strings.TrimSpace(condition)
+	}
+
+	d.breakPoints[index] = b
+	return nil
+}
+
+func (d *Debugger) ClearBreakpoints() {
+	d.breakPoints = map[int]*breakpoint{}
+}
+
+// LineInstruction returns the index of the first instruction starting on or
+// after the line, skipping labels.
+func (d *Debugger) LineInstruction(line int) (int, bool) {
+	for i, instruction := range d.executor.Instructions {
+		if _, ok := instruction.(MarkLabel); ok {
+			continue
+		}
+		if instruction.Span().Start.Line >= line {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+func (d *Debugger) deleteBreakpoint(arg string) {
+	n, ok := d.resolveLocation(arg)
+	if !ok {
+		return
+	}
+
+	delete(d.breakPoints, n)
+}
+
+// resolveLocation converts an instruction index, a label or FILE:LINE to an
+// instruction index, printing why when it can not.
+func (d *Debugger) resolveLocation(location string) (int, bool) {
+	n, err := d.locate(location)
+	if err != nil {
+		fmt.Fprintln(d.Stdout, err.Error())
+		return 0, false
+	}
+	return n, true
+}
+
+// locate converts an instruction index, a label or FILE:LINE to the index of
+// an instruction in the program. Jumps continue from the instruction after a
+// label, so labels resolve to that instruction.
+func (d *Debugger) locate(location string) (int, error) {
+	n, err := d.locateIndex(location)
+	if err == nil && (n < 0 || n >= len(d.executor.Instructions)) {
+		return 0, fmt.Errorf("No instruction %d", n)
+	}
+	return n, err
+}
+
+func (d *Debugger) locateIndex(location string) (int, error) {
+	location = strings.TrimSpace(location)
+
+	if n, err := strconv.Atoi(location); err == nil {
+		return n, nil
+	}
+
+	if file, lineArg, ok := strings.Cut(location, ":"); ok {
+		line, err := strconv.Atoi(lineArg)
+		if err != nil {
+			return 0, fmt.Errorf("Invalid line number: \"%s\"", lineArg)
+		}
+		if file != d.executor.Filename && filepath.Base(file) != filepath.Base(d.executor.Filename) {
+			return 0, fmt.Errorf("No source file named %s", file)
+		}
+
+		n, ok := d.LineInstruction(line)
+		if !ok {
+			return 0, fmt.Errorf("No instruction at or after line %d", line)
+		}
+		return n, nil
+	}
+
+	if n, ok := d.executor.LabelMap[location]; ok {
+		return n + 1, nil
+	}
+
+	return 0, fmt.Errorf("Invalid location: \"%s\", Try \"help\"", location)
+}
+
+// relocateBreakpoints resolves the location of each breakpoint again after
+// the program is reloaded, and deletes the breakpoints which are no longer
+// in the program.
+func (d *Debugger) relocateBreakpoints() {
+	indexes := []int{}
+	for index := range d.breakPoints {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	breakPoints := map[int]*breakpoint{}
+	for _, index := range indexes {
+		b := d.breakPoints[index]
+		n, err := d.locate(b.location)
+		if err != nil {
+			fmt.Fprintf(d.Stdout, "Deleted breakpoint at %d (%s): %s\n", index, b.location, err.Error())
+			continue
+		}
+		breakPoints[n] = b
+	}
+	d.breakPoints = breakPoints
+}
+
+func (d *Debugger) ignoreBreakpoint(arg string) {
+	fields := strings.Fields(arg)
+	if len(fields) != 2 {
+		d.showInvalidArguments("ignore", arg)
+		return
+	}
+
+	n, ok := d.resolveLocation(fields[0])
+	if !ok {
+		return
+	}
+
+	count, err := strconv.Atoi(fields[1])
+	if err != nil || count < 0 {
+		d.showInvalidArguments("ignore", arg)
+		return
+	}
+
+	b, ok := d.breakPoints[n]
+	if !ok {
+		fmt.Fprintf(d.Stdout, "No breakpoint at %d\n", n)
+		return
+	}
+	b.ignoreCount = count
+}
+
+// hitBreakpoint reports whether execution should stop at index, counting the
+// hit when the condition holds.
+func (d *Debugger) hitBreakpoint(index int) bool {
+	if !d.matchBreakpoint(index) {
+		return false
+	}
+
+	b := d.breakPoints[index]
+	b.hitCount++
+	if b.ignoreCount > 0 {
+		b.ignoreCount--
+		return false
+	}
+	return true
+}
+
+func (d *Debugger) matchBreakpoint(index int) bool {
+	b, ok := d.breakPoints[index]
+	if !ok {
+		return false
+	}
+	if b.condition == nil {
+		return true
+	}
+
+	value, err := b.condition(d.executor)
+	if err != nil {
+		fmt.Fprintf(d.Stdout, "Error in condition of breakpoint at %d: %s\n", index, err.Error())
+		return true
+	}
+	return value.Sign() != 0
+}
@@ -0,0 +1,91 @@
+package executor
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/peterh/liner"
+)
+
+// CommandReader supplies debugger commands. Lines the program reads with
+// GETC and GETN are read from it as well unless Debugger.Input is set.
+type CommandReader interface {
+	Prompt(prompt string) (string, error)
+	AppendHistory(item string)
+}
+
+type lineEditor struct {
+	state       *liner.State
+	historyPath string
+}
+
+func newLineEditor(historyPath string) *lineEditor {
+	state := liner.NewLiner()
+	state.SetCtrlCAborts(true)
+	state.SetCompleter(func(line string) (c []string) {
+		for _, command := range commands {
+			if strings.HasPrefix(command, line) {
+				c = append(c, command)
+			}
+		}
+		return
+	})
+
+	if f, err := os.Open(historyPath); err == nil {
+		state.ReadHistory(f)
+		f.Close()
+	}
+
+	return &lineEditor{state: state, historyPath: historyPath}
+}
+
+func (e *lineEditor) Prompt(prompt string) (string, error) {
+	return e.state.Prompt(prompt)
+}
+
+func (e *lineEditor) AppendHistory(item string) {
+	e.state.AppendHistory(item)
+}
+
+func (e *lineEditor) Close() {
+	if f, err := os.Create(e.historyPath); err != nil {
+		log.Print("Error writing history file: ", err)
+	} else {
+		e.state.WriteHistory(f)
+		f.Close()
+	}
+
+	e.state.Close()
+}
+
+type scriptCommands struct {
+	scanner *bufio.Scanner
+	echo    io.Writer
+}
+
+// NewScriptCommands reads commands line by line from reader. Each prompt and
+// command is echoed to echo unless it is nil.
+func NewScriptCommands(reader io.Reader, echo io.Writer) CommandReader {
+	return &scriptCommands{scanner: bufio.NewScanner(reader), echo: echo}
+}
+
+func (s *scriptCommands) Prompt(prompt string) (string, error) {
+	if !s.scanner.Scan() {
+		if err := s.scanner.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+
+	line := strings.TrimRight(s.scanner.Text(), "\r")
+	if s.echo != nil {
+		fmt.Fprintln(s.echo, prompt+line)
+	}
+	return line, nil
+}
+
+func (s *scriptCommands) AppendHistory(item string) {}
@@ -0,0 +1,329 @@
+package executor
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// expression is a breakpoint condition such as "heap[5] == 10 && depth > 2".
+// Comparisons and logical operators evaluate to 1 or 0, and any non-zero
+// value is true.
+//
+//	top        top of the stack
+//	stack[N]   Nth item from the top of the stack
+//	depth      stack depth
+//	calldepth  call stack depth
+//	heap[N]    heap cell at address N
+//	pc         program counter
+type expression func(executor *Executor) (Number, error)
+
+type expressionParser struct {
+	tokens []string
+	index  int
+}
+
+var expressionOperators = []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!", "-", "(", ")", "[", "]"}
+
+func parseExpression(source string) (expression, error) {
+	tokens, err := tokenizeExpression(source)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := &expressionParser{tokens: tokens}
+	expr, err := parser.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if parser.index < len(parser.tokens) {
+		return nil, fmt.Errorf("unexpected \"%s\"", parser.tokens[parser.index])
+	}
+
+	return expr, nil
+}
+
+func tokenizeExpression(source string) ([]string, error) {
+	tokens := []string{}
+	runes := []rune(source)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r):
+			start := i
+			for i < len(runes) && unicode.IsDigit(runes[i]) {
+				i++
+			}
+			tokens = append(tokens, string(runes[start:i]))
+		case unicode.IsLetter(r):
+			start := i
+			for i < len(runes) && unicode.IsLetter(runes[i]) {
+				i++
+			}
+			tokens = append(tokens, strings.ToLower(string(runes[start:i])))
+		default:
+			matched := false
+			for _, operator := range expressionOperators {
+				if strings.HasPrefix(string(runes[i:]), operator) {
+					tokens = append(tokens, operator)
+					i += len([]rune(operator))
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("unexpected character \"%c\"", r)
+			}
+		}
+	}
+
+	return tokens, nil
+}
+
+func (p *expressionParser) peek() string {
+	if p.index >= len(p.tokens) {
+		return ""
+	}
+	return p.tokens[p.index]
+}
+
+func (p *expressionParser) next() string {
+	token := p.peek()
+	p.index++
+	return token
+}
+
+func (p *expressionParser) expect(token string) error {
+	if actual := p.next(); actual != token {
+		if actual == "" {
+			return fmt.Errorf("expected \"%s\", but reached the end", token)
+		}
+		return fmt.Errorf("expected \"%s\", but actual \"%s\"", token, actual)
+	}
+	return nil
+}
+
+func (p *expressionParser) parseOr() (expression, error) {
+	lhs, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek() == "||" {
+		p.next()
+		rhs, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		lhs = logicalExpression(lhs, rhs, true)
+	}
+
+	return lhs, nil
+}
+
+func (p *expressionParser) parseAnd() (expression, error) {
+	lhs, err := p.parseComparison()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek() == "&&" {
+		p.next()
+		rhs, err := p.parseComparison()
+		if err != nil {
+			return nil, err
+		}
+		lhs = logicalExpression(lhs, rhs, false)
+	}
+
+	return lhs, nil
+}
+
+func (p *expressionParser) parseComparison() (expression, error) {
+	lhs, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	operator := p.peek()
+	var compare func(int) bool
+	switch operator {
+	case "==":
+		compare = func(c int) bool { return c == 0 }
+	case "!=":
+		compare = func(c int) bool { return c != 0 }
+	case "<":
+		compare = func(c int) bool { return c < 0 }
+	case "<=":
+		compare = func(c int) bool { return c <= 0 }
+	case ">":
+		compare = func(c int) bool { return c > 0 }
+	case ">=":
+		compare = func(c int) bool { return c >= 0 }
+	default:
+		return lhs, nil
+	}
+	p.next()
+
+	rhs, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	return func(executor *Executor) (Number, error) {
+		l, err := lhs(executor)
+		if err != nil {
+			return Number{}, err
+		}
+		r, err := rhs(executor)
+		if err != nil {
+			return Number{}, err
+		}
+		return boolNumber(compare(l.Cmp(r))), nil
+	}, nil
+}
+
+func (p *expressionParser) parseUnary() (expression, error) {
+	switch p.peek() {
+	case "!":
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(executor *Executor) (Number, error) {
+			value, err := operand(executor)
+			if err != nil {
+				return Number{}, err
+			}
+			return boolNumber(value.Sign() == 0), nil
+		}, nil
+	case "-":
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(executor *Executor) (Number, error) {
+			value, err := operand(executor)
+			if err != nil {
+				return Number{}, err
+			}
+			return NewBigNumber(value.Big().Neg(value.Big())), nil
+		}, nil
+	default:
+		return p.parsePrimary()
+	}
+}
+
+func (p *expressionParser) parsePrimary() (expression, error) {
+	token := p.next()
+
+	switch token {
+	case "":
+		return nil, fmt.Errorf("unexpected end of expression")
+	case "(":
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return expr, p.expect(")")
+	case "top":
+		return func(executor *Executor) (Number, error) {
+			if len(executor.stack) == 0 {
+				return Number{}, fmt.Errorf("stack is empty")
+			}
+			return executor.stack[len(executor.stack)-1], nil
+		}, nil
+	case "depth":
+		return func(executor *Executor) (Number, error) {
+			return NewNumber(len(executor.stack)), nil
+		}, nil
+	case "calldepth":
+		return func(executor *Executor) (Number, error) {
+			return NewNumber(len(executor.callStack)), nil
+		}, nil
+	case "pc":
+		return func(executor *Executor) (Number, error) {
+			return NewNumber(executor.programCounter), nil
+		}, nil
+	case "stack", "heap":
+		if err := p.expect("["); err != nil {
+			return nil, err
+		}
+		index, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+		if token == "stack" {
+			return stackExpression(index), nil
+		}
+		return heapExpression(index), nil
+	}
+
+	if value, ok := ParseNumber(token); ok {
+		return func(executor *Executor) (Number, error) {
+			return value, nil
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unexpected \"%s\"", token)
+}
+
+func stackExpression(index expression) expression {
+	return func(executor *Executor) (Number, error) {
+		n, err := index(executor)
+		if err != nil {
+			return Number{}, err
+		}
+		if !n.IsInt() || n.Int() < 0 || n.Int() >= len(executor.stack) {
+			return Number{}, fmt.Errorf("stack[%s] is out of index. stack length: %d", n, len(executor.stack))
+		}
+		return executor.stack[len(executor.stack)-1-n.Int()], nil
+	}
+}
+
+func heapExpression(address expression) expression {
+	return func(executor *Executor) (Number, error) {
+		n, err := address(executor)
+		if err != nil {
+			return Number{}, err
+		}
+		value, ok := executor.heap.get(n)
+		if !ok {
+			return Number{}, fmt.Errorf("heap[%s] is not stored", n)
+		}
+		return value, nil
+	}
+}
+
+func logicalExpression(lhs, rhs expression, or bool) expression {
+	return func(executor *Executor) (Number, error) {
+		l, err := lhs(executor)
+		if err != nil {
+			return Number{}, err
+		}
+		if (l.Sign() != 0) == or {
+			return boolNumber(or), nil
+		}
+		r, err := rhs(executor)
+		if err != nil {
+			return Number{}, err
+		}
+		return boolNumber(r.Sign() != 0), nil
+	}
+}
+
+func boolNumber(b bool) Number {
+	if b {
+		return NewNumber(1)
+	}
+	return NewNumber(0)
+}
@@ -0,0 +1,55 @@
+package executor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseExpression(t *testing.T) {
+	executor := newExecutor()
+	executor.stack = numbers(3, 7)
+	executor.heap = heapOf(map[int]int{5: 10})
+	executor.callStack = []int{4}
+	executor.programCounter = 2
+
+	tests := []struct {
+		source   string
+		expected int
+	}{
+		{"top", 7},
+		{"stack[1]", 3},
+		{"depth == 2", 1},
+		{"calldepth > 0", 1},
+		{"heap[5] == 10", 1},
+		{"heap[5] != 10 || pc == 2", 1},
+		{"top >= 7 && !(depth < 2)", 1},
+		{"-top < -6", 1},
+		{"top == 3 && heap[1] == 0", 0},
+	}
+
+	for _, tt := range tests {
+		expr, err := parseExpression(tt.source)
+		assert.NoError(t, err, tt.source)
+
+		value, err := expr(executor)
+		assert.NoError(t, err, tt.source)
+		assert.Equal(t, NewNumber(tt.expected), value, tt.source)
+	}
+}
+
+func TestParseExpressionErrors(t *testing.T) {
+	for _, source := range []string{"", "top ==", "heap[5", "top $ 1", "foo", "(top"} {
+		_, err := parseExpression(source)
+		assert.Error(t, err, source)
+	}
+
+	executor := newExecutor()
+	for _, source := range []string{"top", "heap[5]", "stack[3]"} {
+		expr, err := parseExpression(source)
+		assert.NoError(t, err, source)
+
+		_, err = expr(executor)
+		assert.Error(t, err, source)
+	}
+}
@@ -0,0 +1,181 @@
+package executor
+
+import (
+	"fmt"
+	"slices"
+)
+
+const DefaultHistorySize = 1000
+
+type heapWrite struct {
+	address Number
+	value   Number
+	existed bool
+}
+
+type historyEntry struct {
+	programCounter int
+	steps          int
+	// stack keeps the values a step could pop or overwrite, which were on
+	// top of stackLength values in all, and callStack does the same for
+	// the call stack, so that an entry does not copy the whole stacks.
+	stackLength     int
+	stack           []Number
+	callStackLength int
+	callStack       []int
+	heapWrites      []heapWrite
+	outputLength    int
+	input           []inputText
+	// heap and output are kept instead of heapWrites and outputLength by the
+	// entry recorded on restart, which replaces the whole state. The entry
+	// also keeps the input position and the pending input, of which restart
+	// kept keptInput bytes.
+	heap         *heap
+	output       string
+	inputOffset  int64
+	pendingInput []inputText
+	keptInput    int
+}
+
+// recordHistory records the state before a change which pops or overwrites
+// at most n values from the top of the stack and the top of the call stack.
+func (d *Debugger) recordHistory(n int) {
+	if d.HistorySize <= 0 {
+		return
+	}
+
+	d.appendHistory(&historyEntry{
+		programCounter:  d.executor.programCounter,
+		steps:           d.executor.steps,
+		stackLength:     len(d.executor.stack),
+		stack:           topOf(d.executor.stack, n),
+		callStackLength: len(d.executor.callStack),
+		callStack:       topOf(d.executor.callStack, 1),
+		outputLength:    len(d.programOutput),
+	})
+}
+
+// stackChange returns how many values from the top of the stack the
+// instruction may pop or overwrite.
+func (d *Debugger) stackChange(instruction Instruction) int {
+	switch instruction := instruction.(type) {
+	case Push, Copy, MarkLabel, CallSubroutine, EndSubroutine, JumpLabel, EndProgram:
+		return 0
+	case Duplicate, Discard, Getc, Getn, Putc, Putn, Retrieve, JumpLabelWhenZero, JumpLabelWhenNegative:
+		return 1
+	case Swap, Addition, Subtraction, Multiplication, Division, Modulo, Store:
+		return 2
+	case Slide:
+		return instruction.Value + 1
+	default:
+		return len(d.executor.stack)
+	}
+}
+
+func topOf[T any](values []T, n int) []T {
+	return slices.Clone(values[min(max(len(values)-n, 0), len(values)):])
+}
+
+func (d *Debugger) recordRestart() {
+	if d.HistorySize <= 0 {
+		return
+	}
+
+	saved := d.executor.heap.clone()
+	d.appendHistory(&historyEntry{
+		programCounter:  d.executor.programCounter,
+		steps:           d.executor.steps,
+		stackLength:     len(d.executor.stack),
+		stack:           slices.Clone(d.executor.stack),
+		callStackLength: len(d.executor.callStack),
+		callStack:       slices.Clone(d.executor.callStack),
+		heap:            &saved,
+		output:          d.programOutput,
+		inputOffset:     d.executor.inputOffset,
+		pendingInput:    slices.Clone(d.executor.pendingInput),
+		keptInput:       d.executor.unqueuedInputLength(),
+	})
+}
+
+func (d *Debugger) appendHistory(entry *historyEntry) {
+	d.history = append(d.history, entry)
+
+	if len(d.history) > d.HistorySize {
+		d.history = d.history[len(d.history)-d.HistorySize:]
+	}
+}
+
+func (d *Debugger) recordHeapWrite(access HeapAccess) {
+	if access.Kind != HeapWrite || len(d.history) == 0 {
+		return
+	}
+
+	entry := d.history[len(d.history)-1]
+	entry.heapWrites = append(entry.heapWrites, heapWrite{address: access.Address, value: access.Old, existed: access.Existed})
+}
+
+func (d *Debugger) recordInput(input inputText) {
+	if len(d.history) == 0 {
+		return
+	}
+
+	entry := d.history[len(d.history)-1]
+	if n := len(entry.input); n > 0 && entry.input[n-1].queued == input.queued {
+		entry.input[n-1].text += input.text
+		return
+	}
+	entry.input = append(entry.input, input)
+}
+
+func (d *Debugger) reverseStep() bool {
+	if len(d.history) == 0 {
+		fmt.Fprintf(d.Stdout, "No more reverse-execution history.\n")
+		return false
+	}
+
+	entry := d.history[len(d.history)-1]
+	d.history = d.history[:len(d.history)-1]
+
+	if entry.heap != nil {
+		d.executor.heap = *entry.heap
+		d.programOutput = entry.output
+		// the input read since restart has been put back in front of the
+		// kept input, and what follows it was read from Input
+		d.executor.skipPendingInput(entry.keptInput)
+		d.executor.pendingInput = append(slices.Clone(entry.pendingInput), d.executor.pendingInput...)
+		d.executor.inputOffset = entry.inputOffset
+	} else {
+		for i := len(entry.heapWrites) - 1; i >= 0; i-- {
+			write := entry.heapWrites[i]
+			if write.existed {
+				d.executor.heap.set(write.address, write.value)
+			} else {
+				d.executor.heap.delete(write.address)
+			}
+		}
+		d.programOutput = d.programOutput[:entry.outputLength]
+	}
+	d.executor.unreadInput(entry.input)
+
+	d.executor.programCounter = entry.programCounter
+	d.executor.steps = entry.steps
+	d.executor.stack = append(d.executor.stack[:entry.stackLength-len(entry.stack)], entry.stack...)
+	d.executor.callStack = append(d.executor.callStack[:entry.callStackLength-len(entry.callStack)], entry.callStack...)
+	d.state = DebuggerStateInterrupt
+	d.lastoccurredError = nil
+
+	return true
+}
+
+func (d *Debugger) reverseContinue() {
+	if !d.reverseStep() {
+		return
+	}
+
+	for !d.matchBreakpoint(d.executor.programCounter) {
+		if len(d.history) == 0 {
+			return
+		}
+		d.reverseStep()
+	}
+}
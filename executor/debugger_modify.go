@@ -0,0 +1,140 @@
+package executor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/simomu-github/fflt_lang/lexer"
+)
+
+func (d *Debugger) parseValue(arg string) (Number, bool) {
+	value, ok := ParseNumber(arg)
+	if !ok {
+		fmt.Fprintf(d.Stdout, "Invalid number: \"%s\"\n", arg)
+		return Number{}, false
+	}
+
+	value, ok = d.executor.fit(value)
+	if !ok {
+		fmt.Fprintf(d.Stdout, "Integer overflow: %s\n", arg)
+		return Number{}, false
+	}
+	return value, true
+}
+
+// set handles "set stack N VALUE", "set heap ADDR VALUE" and "set pc N".
+func (d *Debugger) set(arg string) {
+	fields := strings.Fields(arg)
+	if len(fields) < 2 {
+		d.showInvalidArguments("set", arg)
+		return
+	}
+
+	switch {
+	case fields[0] == "stack" && len(fields) == 3:
+		n, err := strconv.Atoi(fields[1])
+		if err != nil || n < 0 || n >= len(d.executor.stack) {
+			fmt.Fprintf(d.Stdout, "stack[%s] is out of index. stack length: %d\n", fields[1], len(d.executor.stack))
+			return
+		}
+		if value, ok := d.parseValue(fields[2]); ok {
+			d.recordHistory(n + 1)
+			d.executor.stack[len(d.executor.stack)-1-n] = value
+		}
+	case fields[0] == "heap" && len(fields) == 3:
+		address, ok := d.parseValue(fields[1])
+		if !ok {
+			return
+		}
+		if value, ok := d.parseValue(fields[2]); ok {
+			d.storeHeap(address, value)
+		}
+	case fields[0] == "pc" && len(fields) == 2:
+		n, err := strconv.Atoi(fields[1])
+		if err != nil {
+			d.showInvalidArguments("set", arg)
+			return
+		}
+		d.setProgramCounter(n)
+	default:
+		d.showInvalidArguments("set", arg)
+	}
+}
+
+// storeHeap stores value the way STORE does, so the heap limit and
+// watchpoints apply and reverse-step undoes the store.
+func (d *Debugger) storeHeap(address Number, value Number) {
+	if err := d.executor.checkHeapLimit(lexer.Token{}, address); err != nil {
+		fmt.Fprintf(d.Stdout, "Can not set heap[%s]: %s\n", address, err.(*RuntimeError).Message)
+		return
+	}
+
+	d.recordHistory(0)
+	d.watchHits = nil
+	d.executor.storeHeap(lexer.Token{}, address, value)
+	d.reportWatchpoints("set heap")
+}
+
+// push pushes the value the way PUSH does, so the stack limit applies and
+// reverse-step undoes the push.
+func (d *Debugger) push(arg string) {
+	value, ok := d.parseValue(arg)
+	if !ok {
+		return
+	}
+
+	if err := d.executor.checkStackLimit(lexer.Token{}); err != nil {
+		fmt.Fprintf(d.Stdout, "Can not push %s: %s\n", value, err.(*RuntimeError).Message)
+		return
+	}
+
+	d.recordHistory(0)
+	d.executor.Push(value)
+}
+
+func (d *Debugger) pop() {
+	if len(d.executor.stack) == 0 {
+		fmt.Fprintf(d.Stdout, "Stack is empty\n")
+		return
+	}
+
+	d.recordHistory(1)
+	value, _ := d.executor.Pop()
+	fmt.Fprintf(d.Stdout, "%s\n", value)
+}
+
+func (d *Debugger) jump(arg string) {
+	if n, ok := d.resolveLocation(arg); ok {
+		d.setProgramCounter(n)
+	}
+}
+
+// setProgramCounter moves execution to the index, which reverse-step undoes.
+// It also recovers the debugger from a runtime error so that the program can
+// go on.
+func (d *Debugger) setProgramCounter(n int) {
+	if n < 0 || n >= len(d.executor.Instructions) {
+		fmt.Fprintf(d.Stdout, "Program counter is out of range: %d\n", n)
+		return
+	}
+
+	d.recordHistory(0)
+	d.executor.programCounter = n
+	if d.state == DebuggerStateError {
+		d.state = DebuggerStateInterrupt
+		d.lastoccurredError = nil
+	}
+}
+
+// queueInput handles `input "TEXT"`. TEXT is a Go string literal, so "\n"
+// ends a line for GETN.
+func (d *Debugger) queueInput(arg string) {
+	text, err := strconv.Unquote(strings.TrimSpace(arg))
+	if err != nil {
+		d.showInvalidArguments("input", arg)
+		return
+	}
+
+	d.executor.queueInput(text)
+}
@@ -0,0 +1,37 @@
+package executor
+
+import "fmt"
+
+// restart runs the program again from the beginning, keeping breakpoints,
+// watchpoints and history. With reload, the program is replaced with the one
+// returned by Reload, breakpoints are moved to where their locations are in
+// the new program, and the history, which refers to the old instructions, is
+// cleared. Input queued with the input command is dropped, but the input
+// the program has not read yet is kept.
+func (d *Debugger) restart(reload bool) {
+	if reload {
+		if d.Reload == nil {
+			fmt.Fprintf(d.Stdout, "Reloading is not supported\n")
+			return
+		}
+
+		instructions, labelMap, err := d.Reload()
+		if err != nil {
+			fmt.Fprintln(d.Stderr, err.Error())
+			return
+		}
+		d.executor.Instructions = instructions
+		d.executor.LabelMap = labelMap
+		d.history = nil
+		d.relocateBreakpoints()
+	} else {
+		d.recordRestart()
+	}
+
+	d.executor.reset()
+	d.programOutput = ""
+	d.state = DebuggerStateInterrupt
+	d.stopReason = StopReasonEntry
+	d.lastoccurredError = nil
+	d.stopCondition = nil
+}
@@ -0,0 +1,626 @@
+package executor
+
+import (
+	"bytes"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/simomu-github/fflt_lang/lexer"
+	"github.com/stretchr/testify/assert"
+)
+
+func runDebugger(t *testing.T, instructions []Instruction, labelMap map[string]int, script string) (*Debugger, string) {
+	executor := &Executor{Filename: "test.fflt", Instructions: instructions, LabelMap: labelMap}
+	debugger := NewDebugger(executor)
+
+	output := &bytes.Buffer{}
+	debugger.Stdout = output
+	debugger.Stderr = output
+	debugger.Commands = NewScriptCommands(strings.NewReader(script), nil)
+
+	assert.NoError(t, debugger.Run())
+	return debugger, output.String()
+}
+
+// counts down from 3 to 0
+var countdown = []Instruction{
+	Push{Value: NewNumber(3)},
+	MarkLabel{Label: "F"},
+	Push{Value: NewNumber(1)},
+	Subtraction{},
+	Duplicate{},
+	JumpLabelWhenZero{Label: "L", Target: 7},
+	JumpLabel{Label: "F", Target: 1},
+	MarkLabel{Label: "L"},
+	EndProgram{},
+}
+
+var countdownLabels = map[string]int{"F": 1, "L": 7}
+
+// calls F which pushes 7, then pushes 9
+var subroutine = []Instruction{
+	CallSubroutine{Label: "F", Target: 3},
+	Push{Value: NewNumber(9)},
+	EndProgram{},
+	MarkLabel{Label: "F"},
+	Push{Value: NewNumber(7)},
+	EndSubroutine{},
+}
+
+func TestDebuggerStep(t *testing.T) {
+	debugger, output := runDebugger(t, countdown, countdownLabels, "s\ns\ns\nexit\n")
+
+	assert.Equal(t, 3, debugger.executor.programCounter)
+	assert.Equal(t, numbers(3, 1), debugger.executor.stack)
+	assert.Contains(t, output, "Current instruction: SUB")
+}
+
+func TestDebuggerContinue(t *testing.T) {
+	program := []Instruction{
+		Push{Value: NewNumber(1)},
+		Push{Value: NewNumber(2)},
+		Addition{},
+		Putn{},
+	}
+
+	debugger, output := runDebugger(t, program, map[string]int{}, "c\n")
+
+	assert.Equal(t, 4, debugger.executor.programCounter)
+	assert.Equal(t, "3", debugger.programOutput)
+	assert.Contains(t, output, "3\nProgram exited\n")
+}
+
+func TestDebuggerBreakpoints(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		stack  []Number
+	}{
+		{name: "index", script: "b 4\nc\nexit\n", stack: numbers(2)},
+		{name: "label", script: "b L\nc\nexit\n", stack: numbers(0)},
+		{name: "condition", script: "b 4 if top == 1\nc\nexit\n", stack: numbers(1)},
+		{name: "ignore", script: "b 4\nignore 4 1\nc\nexit\n", stack: numbers(1)},
+		{name: "delete", script: "b 4\nd 4\nc\nexit\n", stack: numbers(0)},
+		{name: "until", script: "until 6\nexit\n", stack: numbers(2)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			debugger, _ := runDebugger(t, countdown, countdownLabels, tt.script)
+
+			assert.Equal(t, tt.stack, debugger.executor.stack)
+		})
+	}
+}
+
+func TestDebuggerInvalidBreakpoint(t *testing.T) {
+	_, output := runDebugger(t, countdown, countdownLabels, "b X\nb 4 if top ==\nb 9\nexit\n")
+
+	assert.Contains(t, output, "Invalid location: \"X\"")
+	assert.Contains(t, output, "Invalid condition: \"top ==\"")
+	assert.Contains(t, output, "No instruction 9")
+}
+
+func TestDebuggerBreakpointAtLabelOnLastInstruction(t *testing.T) {
+	program := []Instruction{
+		Push{Value: NewNumber(1)},
+		MarkLabel{Label: "F"},
+	}
+
+	debugger, output := runDebugger(t, program, map[string]int{"F": 1}, "b F\nexit\n")
+
+	assert.Contains(t, output, "No instruction 2")
+	assert.NotContains(t, output, "Breakpoint at 2")
+	assert.Equal(t, []int{0}, slices.Sorted(maps.Keys(debugger.breakPoints)))
+}
+
+func TestDebuggerBreakpointAtLineWhereInstructionStarts(t *testing.T) {
+	// the PUSH starts on line 1 and its command token ends on line 2
+	program := []Instruction{
+		Push{
+			Source: NewSource(
+				lexer.Token{Line: 2, Start: lexer.Position{Line: 1}, End: lexer.Position{Line: 2}},
+				lexer.Span{Start: lexer.Position{Line: 1}, End: lexer.Position{Line: 3}},
+			),
+			Value: NewNumber(1),
+		},
+		Putn{Source: NewSource(lexer.Token{Line: 4}, lexer.Span{Start: lexer.Position{Line: 4}, End: lexer.Position{Line: 4}})},
+	}
+
+	debugger, output := runDebugger(t, program, map[string]int{}, "b test.fflt:1\nb test.fflt:2\nexit\n")
+
+	assert.Contains(t, output, "Breakpoint at 0\nBreakpoint at 1\n")
+	assert.Equal(t, []int{0, 1}, slices.Sorted(maps.Keys(debugger.breakPoints)))
+}
+
+func TestDebuggerWatchpoint(t *testing.T) {
+	program := []Instruction{
+		Push{Value: NewNumber(1)},
+		Push{Value: NewNumber(5)},
+		Store{},
+		Push{Value: NewNumber(1)},
+		Retrieve{},
+		EndProgram{},
+	}
+
+	debugger, output := runDebugger(t, program, map[string]int{}, "awatch heap[1]\nc\nc\nexit\n")
+
+	assert.Equal(t, 5, debugger.executor.programCounter)
+	assert.Contains(t, output, "Watchpoint heap[1] (write) hit by 0002 STORE\nOld value = <not stored>\nNew value = 5\n")
+	assert.Contains(t, output, "Watchpoint heap[1] (read) hit by 0004 RETRIEVE\nValue = 5\n")
+}
+
+func TestDebuggerNextAndFinish(t *testing.T) {
+	tests := []struct {
+		name           string
+		script         string
+		programCounter int
+		stack          []Number
+		output         string
+	}{
+		{name: "next steps over a call", script: "n\nexit\n", programCounter: 1, stack: numbers(7)},
+		{name: "next steps an instruction", script: "n\nn\nexit\n", programCounter: 2, stack: numbers(7, 9)},
+		{name: "next stops at a breakpoint in the call", script: "b 4\nn\nexit\n", programCounter: 4, stack: nil},
+		{name: "finish returns from the call", script: "s\nfinish\nexit\n", programCounter: 1, stack: numbers(7)},
+		{name: "finish in the outermost frame", script: "finish\nexit\n", programCounter: 0, stack: nil, output: "\"finish\" not meaningful in the outermost frame.\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			debugger, output := runDebugger(t, subroutine, map[string]int{"F": 3}, tt.script)
+
+			assert.Equal(t, tt.programCounter, debugger.executor.programCounter)
+			assert.Equal(t, tt.stack, debugger.executor.stack)
+			assert.Contains(t, output, tt.output)
+		})
+	}
+}
+
+func TestDebuggerUntil(t *testing.T) {
+	line := func(n int) *Source {
+		return NewSource(lexer.Token{Line: n}, lexer.Span{Start: lexer.Position{Line: n}, End: lexer.Position{Line: n}})
+	}
+	program := []Instruction{
+		CallSubroutine{Source: line(1), Label: "F", Target: 3},
+		Push{Source: line(2), Value: NewNumber(9)},
+		EndProgram{Source: line(3)},
+		MarkLabel{Source: line(4), Label: "F"},
+		Push{Source: line(5), Value: NewNumber(7)},
+		EndSubroutine{Source: line(6)},
+	}
+
+	tests := []struct {
+		name           string
+		script         string
+		programCounter int
+		stack          []Number
+		output         string
+	}{
+		{name: "index", script: "until 2\nexit\n", programCounter: 2, stack: numbers(7, 9)},
+		{name: "label", script: "until F\nexit\n", programCounter: 4, stack: nil},
+		{name: "source line", script: "until test.fflt:2\nexit\n", programCounter: 1, stack: numbers(7)},
+		{name: "label line", script: "until test.fflt:4\nexit\n", programCounter: 4, stack: nil},
+		{name: "breakpoint on the way", script: "b F\nuntil 2\nexit\n", programCounter: 4, stack: nil},
+		{name: "invalid location", script: "until X\nexit\n", programCounter: 0, stack: nil, output: "Invalid location: \"X\""},
+		{name: "unknown file", script: "until other.fflt:2\nexit\n", programCounter: 0, stack: nil, output: "No source file named other.fflt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			debugger, output := runDebugger(t, program, map[string]int{"F": 3}, tt.script)
+
+			assert.Equal(t, tt.programCounter, debugger.executor.programCounter)
+			assert.Equal(t, tt.stack, debugger.executor.stack)
+			assert.Contains(t, output, tt.output)
+		})
+	}
+}
+
+func TestDebuggerReverseStep(t *testing.T) {
+	debugger, _ := runDebugger(t, countdown, countdownLabels, "s\ns\ns\ns\nrs\nrs\nexit\n")
+
+	assert.Equal(t, 2, debugger.executor.programCounter)
+	assert.Equal(t, numbers(3), debugger.executor.stack)
+}
+
+func TestDebuggerReverseStepRestoresStacks(t *testing.T) {
+	program := []Instruction{
+		Push{Value: NewNumber(1)},
+		Push{Value: NewNumber(2)},
+		Push{Value: NewNumber(3)},
+		CallSubroutine{Label: "F", Target: 5},
+		EndProgram{},
+		MarkLabel{Label: "F"},
+		Slide{Value: 1},
+		EndSubroutine{},
+	}
+
+	debugger, _ := runDebugger(t, program, map[string]int{"F": 5}, "s\ns\ns\ns\ns\ns\nrs\nrs\nexit\n")
+
+	assert.Equal(t, 6, debugger.executor.programCounter)
+	assert.Equal(t, numbers(1, 2, 3), debugger.executor.stack)
+	assert.Equal(t, []int{3}, debugger.executor.callStack)
+}
+
+func TestDebuggerProgramInput(t *testing.T) {
+	program := []Instruction{
+		Push{Value: NewNumber(0)},
+		Getn{},
+		Push{Value: NewNumber(0)},
+		Retrieve{},
+		Putn{},
+	}
+
+	debugger, _ := runDebugger(t, program, map[string]int{}, "c\n42\n")
+
+	assert.Equal(t, "42", debugger.programOutput)
+}
+
+func TestDebuggerInput(t *testing.T) {
+	program := []Instruction{
+		Push{Value: NewNumber(0)},
+		Getn{},
+		Push{Value: NewNumber(0)},
+		Retrieve{},
+		Putn{},
+	}
+
+	executor := &Executor{Filename: "test.fflt", Instructions: program, LabelMap: map[string]int{}}
+	debugger := NewDebugger(executor)
+	debugger.Stdout = &bytes.Buffer{}
+	debugger.Commands = NewScriptCommands(strings.NewReader("c\n"), nil)
+	debugger.Input = strings.NewReader("42\n")
+
+	assert.NoError(t, debugger.Run())
+	assert.Equal(t, "42", debugger.programOutput)
+	assert.Equal(t, int64(3), executor.inputOffset)
+}
+
+func TestDebuggerRuntimeError(t *testing.T) {
+	debugger, output := runDebugger(t, []Instruction{Putn{}}, map[string]int{}, "s\ns\nexit\n")
+
+	assert.Equal(t, DebuggerState(DebuggerStateExit), debugger.state)
+	assert.Contains(t, output, "Runtime error: stack is empty")
+	assert.Contains(t, output, "Runtime error occured")
+}
+
+func TestDebuggerModifyState(t *testing.T) {
+	program := []Instruction{
+		Push{Value: NewNumber(1)},
+		Push{Value: NewNumber(2)},
+		Putn{},
+		Putn{},
+	}
+
+	tests := []struct {
+		name   string
+		script string
+		stack  []Number
+		heap   heap
+		output string
+	}{
+		{name: "set stack", script: "s\ns\nset stack 1 5\nexit\n", stack: numbers(5, 2), heap: heapOf(map[int]int{})},
+		{name: "push and pop", script: "push 3\npush 4\npop\nexit\n", stack: numbers(3), heap: heapOf(map[int]int{}), output: "4\n"},
+		{name: "reverse set stack", script: "s\ns\nset stack 1 5\nrs\nexit\n", stack: numbers(1, 2), heap: heapOf(map[int]int{})},
+		{name: "reverse push and pop", script: "push 3\npush 4\npop\nrs\nrs\nexit\n", stack: numbers(3), heap: heapOf(map[int]int{})},
+		{name: "set heap", script: "set heap 1 10\nexit\n", stack: nil, heap: heapOf(map[int]int{1: 10})},
+		{name: "set pc", script: "set pc 1\ns\nexit\n", stack: numbers(2), heap: heapOf(map[int]int{})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			debugger, output := runDebugger(t, program, map[string]int{}, tt.script)
+
+			assert.Equal(t, tt.stack, debugger.executor.stack)
+			assert.Equal(t, tt.heap, debugger.executor.heap)
+			assert.Contains(t, output, tt.output)
+		})
+	}
+}
+
+func TestDebuggerSetHeapLikeStore(t *testing.T) {
+	program := []Instruction{
+		Push{Value: NewNumber(1)},
+		EndProgram{},
+	}
+
+	debugger, output := runDebugger(t, program, map[string]int{}, "watch heap[1]\nset heap 1 10\nexit\n")
+	assert.Equal(t, heapOf(map[int]int{1: 10}), debugger.executor.heap)
+	assert.Contains(t, output, "Watchpoint heap[1] (write) hit by set heap\nOld value = <not stored>\nNew value = 10\n")
+
+	debugger, _ = runDebugger(t, program, map[string]int{}, "set heap 1 10\nset heap 1 20\nrs\nexit\n")
+	assert.Equal(t, heapOf(map[int]int{1: 10}), debugger.executor.heap)
+
+	executor := &Executor{Filename: "test.fflt", Instructions: program, Limits: Limits{MaxHeap: 1}}
+	debugger = NewDebugger(executor)
+	out := &bytes.Buffer{}
+	debugger.Stdout = out
+	debugger.Commands = NewScriptCommands(strings.NewReader("set heap 1 10\nset heap 2 20\nset heap 1 30\nexit\n"), nil)
+	assert.NoError(t, debugger.Run())
+	assert.Equal(t, heapOf(map[int]int{1: 30}), debugger.executor.heap)
+	assert.Contains(t, out.String(), "Can not set heap[2]: heap cells exceeded the limit (1)\n")
+}
+
+func TestDebuggerPushLimit(t *testing.T) {
+	executor := &Executor{Filename: "test.fflt", Instructions: []Instruction{EndProgram{}}, Limits: Limits{MaxStack: 1}}
+	debugger := NewDebugger(executor)
+	output := &bytes.Buffer{}
+	debugger.Stdout = output
+	debugger.Commands = NewScriptCommands(strings.NewReader("push 1\npush 2\nexit\n"), nil)
+
+	assert.NoError(t, debugger.Run())
+	assert.Equal(t, numbers(1), executor.stack)
+	assert.Contains(t, output.String(), "Can not push 2: stack size exceeded the limit (1)\n")
+}
+
+func TestDebuggerJumpAfterError(t *testing.T) {
+	program := []Instruction{
+		Putn{},
+		MarkLabel{Label: "F"},
+		Push{Value: NewNumber(7)},
+		Putn{},
+	}
+
+	debugger, _ := runDebugger(t, program, map[string]int{"F": 1}, "s\njump F\nc\n")
+
+	assert.Equal(t, "7", debugger.programOutput)
+}
+
+func TestDebuggerReverseStepOverJump(t *testing.T) {
+	debugger, _ := runDebugger(t, countdown, countdownLabels, "s\ns\njump L\nrs\nexit\n")
+
+	assert.Equal(t, 2, debugger.executor.programCounter)
+	assert.Equal(t, numbers(3), debugger.executor.stack)
+	assert.Len(t, debugger.history, 2)
+}
+
+func TestDebuggerQueueInput(t *testing.T) {
+	program := []Instruction{
+		Push{Value: NewNumber(0)},
+		Getn{},
+		Push{Value: NewNumber(1)},
+		Getc{},
+	}
+
+	debugger, _ := runDebugger(t, program, map[string]int{}, "input \"42\\nx\"\nc\n")
+
+	assert.Equal(t, heapOf(map[int]int{0: 42, 1: 'x'}), debugger.executor.heap)
+}
+
+func TestDebuggerQueueInputTwice(t *testing.T) {
+	program := []Instruction{
+		Push{Value: NewNumber(0)},
+		Getc{},
+		Push{Value: NewNumber(1)},
+		Getc{},
+	}
+
+	debugger, output := runDebugger(t, program, map[string]int{}, "input \"a\"\ninput \"b\"\nc\n")
+
+	assert.NotContains(t, output, "input is empty")
+	assert.Equal(t, heapOf(map[int]int{0: 'a', 1: 'b'}), debugger.executor.heap)
+}
+
+func TestDebuggerQueueInputAfterReverseStep(t *testing.T) {
+	program := []Instruction{
+		Push{Value: NewNumber(0)},
+		Getc{},
+		Push{Value: NewNumber(1)},
+		Getc{},
+		Push{Value: NewNumber(2)},
+		Getc{},
+	}
+
+	executor := &Executor{Filename: "test.fflt", Instructions: program, LabelMap: map[string]int{}}
+	debugger := NewDebugger(executor)
+	output := &bytes.Buffer{}
+	debugger.Stdout = output
+	debugger.Stderr = output
+	debugger.Commands = NewScriptCommands(strings.NewReader("s\ns\nrs\ninput \"x\"\nc\n"), nil)
+	debugger.Input = strings.NewReader("ab")
+
+	assert.NoError(t, debugger.Run())
+	assert.NotContains(t, output.String(), "input is empty")
+	assert.Equal(t, heapOf(map[int]int{0: 'a', 1: 'x', 2: 'b'}), executor.heap)
+	// the queued "x" is not read from Input
+	assert.Equal(t, int64(2), executor.inputOffset)
+}
+
+func TestDebuggerRestart(t *testing.T) {
+	debugger, output := runDebugger(t, countdown, countdownLabels, "b 4\nc\nwatch heap[1]\nrestart\nc\nexit\n")
+
+	assert.Equal(t, 4, debugger.executor.programCounter)
+	assert.Equal(t, numbers(2), debugger.executor.stack)
+	assert.Equal(t, 2, debugger.breakPoints[4].hitCount)
+	assert.Contains(t, debugger.watchPoints, NewNumber(1))
+	assert.Contains(t, output, "Breakpoint")
+
+	// the program ends before restarting
+	debugger, output = runDebugger(t, countdown, countdownLabels, "c\nrestart\ns\nexit\n")
+
+	assert.Contains(t, output, "Program exited")
+	assert.Equal(t, 1, debugger.executor.programCounter)
+	assert.Equal(t, numbers(3), debugger.executor.stack)
+}
+
+func TestDebuggerReverseStepOverRestart(t *testing.T) {
+	program := []Instruction{
+		Push{Value: NewNumber(1)},
+		Push{Value: NewNumber(5)},
+		Store{},
+		Push{Value: NewNumber(7)},
+		Putn{},
+	}
+
+	debugger, _ := runDebugger(t, program, map[string]int{}, "c\nrestart\nrs\nexit\n")
+
+	assert.Equal(t, 5, debugger.executor.programCounter)
+	assert.Equal(t, heapOf(map[int]int{1: 5}), debugger.executor.heap)
+	assert.Equal(t, "7", debugger.programOutput)
+}
+
+func TestDebuggerRestartKeepsUnreadInput(t *testing.T) {
+	program := []Instruction{
+		Push{Value: NewNumber(0)},
+		Getc{},
+		Push{Value: NewNumber(0)},
+		Retrieve{},
+		Putc{},
+	}
+
+	executor := &Executor{Filename: "test.fflt", Instructions: program, LabelMap: map[string]int{}}
+	debugger := NewDebugger(executor)
+	output := &bytes.Buffer{}
+	debugger.Stdout = output
+	debugger.Stderr = output
+	debugger.Commands = NewScriptCommands(strings.NewReader("c\nrestart\nc\nexit\n"), nil)
+	debugger.Input = strings.NewReader("abc")
+
+	assert.NoError(t, debugger.Run())
+	assert.NotContains(t, output.String(), "input is empty")
+	assert.Equal(t, "b", debugger.programOutput)
+	assert.Equal(t, int64(1), executor.inputOffset)
+}
+
+func TestDebuggerReverseStepOverRestartRestoresInput(t *testing.T) {
+	program := []Instruction{
+		Push{Value: NewNumber(0)},
+		Getc{},
+		Push{Value: NewNumber(1)},
+		Getc{},
+	}
+
+	tests := []struct {
+		name   string
+		script string
+		heap   heap
+		offset int64
+	}{
+		{name: "input", script: "s\ns\nrestart\ns\ns\nrs\nrs\nrs\nc\n", heap: heapOf(map[int]int{0: 'a', 1: 'b'}), offset: 2},
+		{name: "queued input", script: "input \"xy\"\ns\ns\nrestart\nrs\nc\n", heap: heapOf(map[int]int{0: 'x', 1: 'y'}), offset: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor := &Executor{Filename: "test.fflt", Instructions: program, LabelMap: map[string]int{}}
+			debugger := NewDebugger(executor)
+			debugger.Stdout = &bytes.Buffer{}
+			debugger.Stderr = &bytes.Buffer{}
+			debugger.Commands = NewScriptCommands(strings.NewReader(tt.script), nil)
+			debugger.Input = strings.NewReader("abc")
+
+			assert.NoError(t, debugger.Run())
+			assert.Equal(t, tt.heap, executor.heap)
+			assert.Equal(t, tt.offset, executor.inputOffset)
+		})
+	}
+}
+
+func TestDebuggerRestartReload(t *testing.T) {
+	executor := &Executor{Filename: "test.fflt", Instructions: countdown, LabelMap: countdownLabels}
+	debugger := NewDebugger(executor)
+	debugger.Stdout = &bytes.Buffer{}
+	debugger.Commands = NewScriptCommands(strings.NewReader("s\nrestart reload\nc\nexit\n"), nil)
+	debugger.Reload = func() ([]Instruction, map[string]int, error) {
+		return []Instruction{Push{Value: NewNumber(8)}, Putn{}}, map[string]int{}, nil
+	}
+
+	assert.NoError(t, debugger.Run())
+	assert.Equal(t, "8", debugger.programOutput)
+	assert.Len(t, debugger.history, 2)
+
+	_, output := runDebugger(t, countdown, countdownLabels, "restart reload\nexit\n")
+
+	assert.Contains(t, output, "Reloading is not supported")
+}
+
+func TestDebuggerRestartReloadMovesBreakpoints(t *testing.T) {
+	line := func(n int) *Source {
+		return NewSource(lexer.Token{Line: n}, lexer.Span{Start: lexer.Position{Line: n}, End: lexer.Position{Line: n}})
+	}
+	program := []Instruction{
+		Push{Source: line(1), Value: NewNumber(1)},
+		Push{Source: line(2), Value: NewNumber(2)},
+		MarkLabel{Source: line(3), Label: "F"},
+		Putn{Source: line(4)},
+		Putn{Source: line(5)},
+		EndProgram{Source: line(6)},
+	}
+	reloaded := []Instruction{
+		Push{Source: line(1), Value: NewNumber(1)},
+		MarkLabel{Source: line(2), Label: "F"},
+		Putn{Source: line(3)},
+		Push{Source: line(4), Value: NewNumber(2)},
+		Putn{Source: line(5)},
+	}
+
+	executor := &Executor{Filename: "test.fflt", Instructions: program, LabelMap: map[string]int{"F": 2}}
+	debugger := NewDebugger(executor)
+	output := &bytes.Buffer{}
+	debugger.Stdout = output
+	debugger.Commands = NewScriptCommands(strings.NewReader("b F\nb test.fflt:5\nb 5\nrestart reload\nexit\n"), nil)
+	debugger.Reload = func() ([]Instruction, map[string]int, error) {
+		return reloaded, map[string]int{"F": 1}, nil
+	}
+
+	assert.NoError(t, debugger.Run())
+	assert.Equal(t, []int{0, 2, 4}, slices.Sorted(maps.Keys(debugger.breakPoints)))
+	assert.Contains(t, output.String(), "Deleted breakpoint at 5 (5): No instruction 5\n")
+}
+
+func TestDebuggerRestartDropsQueuedInput(t *testing.T) {
+	program := []Instruction{
+		Push{Value: NewNumber(0)},
+		Getc{},
+		EndProgram{},
+	}
+
+	debugger, _ := runDebugger(t, program, map[string]int{}, "input \"ab\"\ns\ns\nrestart\ninput \"c\"\nc\n")
+
+	assert.Equal(t, heapOf(map[int]int{0: 'c'}), debugger.executor.heap)
+	assert.Equal(t, int64(0), debugger.executor.inputOffset)
+}
+
+func TestDebuggerReverseStepOverStore(t *testing.T) {
+	program := []Instruction{
+		Push{Value: NewNumber(1)},
+		Push{Value: NewNumber(5)},
+		Store{},
+		Push{Value: NewNumber(1)},
+		Push{Value: NewNumber(6)},
+		Store{},
+	}
+
+	tests := []struct {
+		name   string
+		script string
+		heap   heap
+	}{
+		{name: "overwrite", script: "s\ns\ns\ns\ns\ns\nrs\nexit\n", heap: heapOf(map[int]int{1: 5})},
+		{name: "first store", script: "s\ns\ns\ns\ns\ns\nrs\nrs\nrs\nrs\nexit\n", heap: heapOf(map[int]int{})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			debugger, _ := runDebugger(t, program, map[string]int{}, tt.script)
+
+			assert.Equal(t, tt.heap, debugger.executor.heap)
+		})
+	}
+}
+
+func TestDebuggerReverseStepOverGetc(t *testing.T) {
+	program := []Instruction{
+		Push{Value: NewNumber(0)},
+		Getc{},
+		Push{Value: NewNumber(1)},
+		Getc{},
+	}
+
+	debugger, _ := runDebugger(t, program, map[string]int{}, "s\ns\nab\nrs\ns\ns\ns\nexit\n")
+
+	assert.Equal(t, heapOf(map[int]int{0: 'a', 1: 'b'}), debugger.executor.heap)
+	assert.Equal(t, int64(2), debugger.executor.inputOffset)
+}
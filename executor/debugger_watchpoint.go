@@ -0,0 +1,111 @@
+package executor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type watchpoint struct {
+	command  string
+	hitCount int
+}
+
+func (w *watchpoint) matches(kind HeapAccessKind) bool {
+	switch w.command {
+	case "rwatch":
+		return kind == HeapRead
+	case "awatch":
+		return true
+	default:
+		return kind == HeapWrite
+	}
+}
+
+func (d *Debugger) onHeapAccess(access HeapAccess) {
+	d.recordHeapWrite(access)
+
+	w, ok := d.watchPoints[access.Address]
+	if !ok || !w.matches(access.Kind) {
+		return
+	}
+
+	w.hitCount++
+	d.watchHits = append(d.watchHits, access)
+}
+
+func (d *Debugger) setWatchpoint(command, arg string) {
+	address, ok := parseHeapAddress(arg)
+	if !ok {
+		d.showInvalidArguments(command, arg)
+		return
+	}
+
+	d.watchPoints[address] = &watchpoint{command: command}
+}
+
+func (d *Debugger) deleteWatchpoint(arg string) {
+	address, ok := parseHeapAddress(arg)
+	if !ok {
+		d.showInvalidArguments("unwatch", arg)
+		return
+	}
+
+	delete(d.watchPoints, address)
+}
+
+// reportWatchpoints prints the watchpoints hit by source, e.g. the instruction
+// just executed, and reports whether any was hit.
+func (d *Debugger) reportWatchpoints(source string) bool {
+	if len(d.watchHits) == 0 {
+		return false
+	}
+
+	for _, access := range d.watchHits {
+		fmt.Fprintf(d.Stdout, "\n")
+		fmt.Fprintf(d.Stdout, "Watchpoint heap[%s] (%s) hit by %s\n", access.Address, access.Kind, source)
+		if access.Kind == HeapRead {
+			fmt.Fprintf(d.Stdout, "Value = %s\n", access.New)
+			continue
+		}
+		if access.Existed {
+			fmt.Fprintf(d.Stdout, "Old value = %s\n", access.Old)
+		} else {
+			fmt.Fprintf(d.Stdout, "Old value = <not stored>\n")
+		}
+		fmt.Fprintf(d.Stdout, "New value = %s\n", access.New)
+	}
+	d.watchHits = nil
+
+	return true
+}
+
+func (d *Debugger) showWatchpoints() {
+	table := d.newTable("Watchpoints", "Type", "Hits")
+	addresses := []Number{}
+	for address := range d.watchPoints {
+		addresses = append(addresses, address)
+	}
+	sort.Slice(addresses, func(i, j int) bool {
+		return addresses[i].Cmp(addresses[j]) < 0
+	})
+	for _, address := range addresses {
+		w := d.watchPoints[address]
+		table.Append([]string{
+			fmt.Sprintf("heap[%s]", address),
+			w.command,
+			fmt.Sprintf("%d", w.hitCount),
+		})
+	}
+	fmt.Fprintf(d.Stdout, "\n")
+	table.Render()
+}
+
+func parseHeapAddress(arg string) (Number, bool) {
+	arg = strings.TrimSpace(arg)
+	if !strings.HasPrefix(arg, "heap[") || !strings.HasSuffix(arg, "]") {
+		return Number{}, false
+	}
+
+	return ParseNumber(arg[len("heap[") : len(arg)-1])
+}
@@ -1,18 +1,86 @@
 package executor
 
 import (
-	"errors"
 	"fmt"
 
 	"github.com/simomu-github/fflt_lang/lexer"
 )
 
-func runtimeError(executor *Executor, message string) error {
-	errorMessage := fmt.Sprintf("Runtime error: %s", message)
-	return errors.New(errorMessage)
+type RuntimeErrorKind string
+
+const (
+	StackUnderflow     = RuntimeErrorKind("StackUnderflow")
+	CallStackUnderflow = RuntimeErrorKind("CallStackUnderflow")
+	StackOutOfRange    = RuntimeErrorKind("StackOutOfRange")
+	InvalidParameter   = RuntimeErrorKind("InvalidParameter")
+	DivideByZero       = RuntimeErrorKind("DivideByZero")
+	IntegerOverflow    = RuntimeErrorKind("IntegerOverflow")
+	InvalidHeapAccess  = RuntimeErrorKind("InvalidHeapAccess")
+	InputEmpty         = RuntimeErrorKind("InputEmpty")
+	InvalidInput       = RuntimeErrorKind("InvalidInput")
+	IOError            = RuntimeErrorKind("IOError")
+
+	StepLimitExceeded      = RuntimeErrorKind("StepLimitExceeded")
+	StackLimitExceeded     = RuntimeErrorKind("StackLimitExceeded")
+	CallDepthLimitExceeded = RuntimeErrorKind("CallDepthLimitExceeded")
+	HeapLimitExceeded      = RuntimeErrorKind("HeapLimitExceeded")
+
+	Canceled = RuntimeErrorKind("Canceled")
+)
+
+type RuntimeError struct {
+	Kind           RuntimeErrorKind
+	Message        string
+	Filename       string
+	Line           int
+	Column         int
+	ProgramCounter int
+	Instruction    Instruction
+	Err            error
+	hasPosition    bool
+}
+
+func (e *RuntimeError) Error() string {
+	if !e.hasPosition {
+		return fmt.Sprintf("Runtime error: %s", e.Message)
+	}
+	return fmt.Sprintf("Runtime error: %s at %s:%d:%d", e.Message, e.Filename, e.Line, e.Column)
+}
+
+func (e *RuntimeError) Unwrap() error {
+	return e.Err
+}
+
+func runtimeError(executor *Executor, kind RuntimeErrorKind, message string) error {
+	return &RuntimeError{
+		Kind:           kind,
+		Message:        message,
+		Filename:       executor.Filename,
+		ProgramCounter: executor.programCounter,
+		Instruction:    executor.currentInstruction(),
+	}
+}
+
+func runtimeErrorWithToken(executor *Executor, token lexer.Token, kind RuntimeErrorKind, message string) error {
+	return &RuntimeError{
+		Kind:           kind,
+		Message:        message,
+		Filename:       executor.Filename,
+		Line:           token.Line,
+		Column:         token.Column,
+		ProgramCounter: executor.programCounter,
+		Instruction:    executor.currentInstruction(),
+		hasPosition:    true,
+	}
 }
 
-func runtimeErrorWithToken(executor *Executor, token lexer.Token, message string) error {
-	errorMessage := fmt.Sprintf("Runtime error: %s at %s:%d:%d", message, executor.Filename, token.Line, token.Column)
-	return errors.New(errorMessage)
+func canceledError(executor *Executor, err error) error {
+	return &RuntimeError{
+		Kind:           Canceled,
+		Message:        fmt.Sprintf("%s at program counter %d", err.Error(), executor.programCounter),
+		Filename:       executor.Filename,
+		ProgramCounter: executor.programCounter,
+		Instruction:    executor.currentInstruction(),
+		Err:            err,
+	}
 }
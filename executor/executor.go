@@ -1,28 +1,137 @@
 package executor
 
 import (
+	"bufio"
+	"bytes"
+	"context"
 	"fmt"
+	"io"
+
+	"github.com/simomu-github/fflt_lang/lexer"
 )
 
 type Executor struct {
-	Filename       string
-	Instructions   []Instruction
-	LabelMap       map[string]int
-	Input          func() string
-	Output         func(string)
-	stack          []int
-	heap           map[int]int
+	Filename      string
+	Instructions  []Instruction
+	LabelMap      map[string]int
+	Input         io.Reader
+	Output        io.Writer
+	Arithmetic    ArithmeticMode
+	EOF           EOFPolicy
+	Limits        Limits
+	RecordOutput  bool
+	bufferedInput *bufio.Reader
+	// pendingInput is read before Input: text put back by reverse-step in
+	// front of text queued by the debugger's input command.
+	pendingInput []inputText
+	// inputOffset counts the bytes read from Input and not put back, so that
+	// it leaves out the input queued by the debugger.
+	inputOffset    int64
+	bufferedOutput *bufio.Writer
+	stack          []Number
+	heap           heap
 	programCounter int
 	callStack      []int
+	steps          int
+	outputOffset   int64
+	output         bytes.Buffer
+	OnHeapAccess   func(access HeapAccess)
+	// onInput is called with the bytes each GETC or GETN reads, once for
+	// the part queued by the debugger and for the rest.
+	onInput func(input inputText)
+}
+
+type HeapAccessKind string
+
+const (
+	HeapRead  = HeapAccessKind("read")
+	HeapWrite = HeapAccessKind("write")
+)
+
+// HeapAccess describes a heap read or write passed to Executor.OnHeapAccess.
+// Old is the value before the access and Existed reports whether the address
+// had been stored. For a read, New is the same as Old.
+type HeapAccess struct {
+	Kind    HeapAccessKind
+	Address Number
+	Old     Number
+	New     Number
+	Existed bool
 }
 
+const cancellationCheckInterval = 1024
+
 func (executor *Executor) Run() error {
-	executor.heap = map[int]int{}
+	return executor.RunContext(context.Background())
+}
+
+// RunContext runs the program until it ends or ctx is done. Cancellation is
+// checked every cancellationCheckInterval instructions, so a program blocked
+// on reading input is not interrupted.
+func (executor *Executor) RunContext(ctx context.Context) error {
+	executor.heap = newHeap()
 	executor.programCounter = 0
+	executor.steps = 0
+
+	return executor.Resume(ctx)
+}
+
+// Resume continues the program from the current state, e.g. after Restore.
+func (executor *Executor) Resume(ctx context.Context) error {
+	if executor.heap.small == nil {
+		executor.heap = newHeap()
+	}
+
+	var err error
+	if executor.Limits != (Limits{}) || ctx.Done() != nil {
+		err = executor.runChecked(ctx)
+	} else {
+		err = executor.run()
+	}
+
+	if err != nil {
+		executor.Flush()
+		return err
+	}
+
+	return executor.Flush()
+}
+
+// run executes the instructions without checking limits or cancellation.
+func (executor *Executor) run() error {
+	if executor.canRunWrap() {
+		if err := executor.runWrap(); err != nil {
+			return err
+		}
+	}
+
+	steps := 0
+	defer func() { executor.steps += steps }()
+
+	for ; executor.programCounter < len(executor.Instructions); executor.programCounter++ {
+		steps++
+		if err := executor.Instructions[executor.programCounter].Execute(executor); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// runChecked executes the instructions checking the limits and checking ctx
+// every cancellationCheckInterval instructions.
+func (executor *Executor) runChecked(ctx context.Context) error {
+	untilCheck := 0
+	for ; executor.programCounter < len(executor.Instructions); executor.programCounter++ {
+		if untilCheck == 0 {
+			if err := ctx.Err(); err != nil {
+				return canceledError(executor, err)
+			}
+			untilCheck = cancellationCheckInterval
+		}
+		untilCheck--
 
-	for executor.programCounter = 0; executor.programCounter < len(executor.Instructions); executor.programCounter++ {
-		err := executor.Instructions[executor.programCounter].Execute(executor)
-		if err != nil {
+		if err := executor.execute(); err != nil {
 			return err
 		}
 	}
@@ -30,19 +139,47 @@ func (executor *Executor) Run() error {
 	return nil
 }
 
-func (executor *Executor) Disassenble() {
+// reset clears the VM state for running the program again. Input is not
+// rewound, but the input queued so far is dropped and the input position
+// starts again from 0.
+func (executor *Executor) reset() {
+	executor.dropQueuedInput()
+	executor.inputOffset = 0
+	executor.stack = nil
+	executor.heap = newHeap()
+	executor.programCounter = 0
+	executor.callStack = nil
+	executor.steps = 0
+	executor.outputOffset = 0
+	executor.output.Reset()
+}
+
+func (executor *Executor) execute() error {
+	if err := executor.checkStepLimit(); err != nil {
+		return err
+	}
+
+	executor.steps++
+	return executor.Instructions[executor.programCounter].Execute(executor)
+}
+
+func (executor *Executor) Disassenble() error {
 	for i, ins := range executor.Instructions {
-		executor.Output(fmt.Sprintf("%04d %s\n", i, ins.Disassenble()))
+		if err := executor.write(fmt.Sprintf("%04d %s\n", i, ins.Disassenble())); err != nil {
+			return err
+		}
 	}
+
+	return executor.Flush()
 }
 
-func (executor *Executor) Push(value int) {
+func (executor *Executor) Push(value Number) {
 	executor.stack = append(executor.stack, value)
 }
 
-func (executor *Executor) Pop() (int, error) {
+func (executor *Executor) Pop() (Number, error) {
 	if len(executor.stack) == 0 {
-		return 0, runtimeError(executor, "stack is epmty")
+		return Number{}, runtimeError(executor, StackUnderflow, "stack is empty")
 	}
 
 	value := executor.stack[len(executor.stack)-1]
@@ -50,16 +187,50 @@ func (executor *Executor) Pop() (int, error) {
 	return value, nil
 }
 
+func (executor *Executor) storeHeap(token lexer.Token, address Number, value Number) error {
+	if err := executor.checkHeapLimit(token, address); err != nil {
+		return err
+	}
+
+	if executor.OnHeapAccess != nil {
+		old, existed := executor.heap.get(address)
+		executor.OnHeapAccess(HeapAccess{Kind: HeapWrite, Address: address, Old: old, New: value, Existed: existed})
+	}
+
+	executor.heap.set(address, value)
+	return nil
+}
+
+func (executor *Executor) retrieveHeap(token lexer.Token, address Number) (Number, error) {
+	value, ok := executor.heap.get(address)
+	if !ok {
+		return Number{}, runtimeErrorWithToken(executor, token, InvalidHeapAccess, "invalid heap access")
+	}
+
+	if executor.OnHeapAccess != nil {
+		executor.OnHeapAccess(HeapAccess{Kind: HeapRead, Address: address, Old: value, New: value, Existed: true})
+	}
+
+	return value, nil
+}
+
 func (executor *Executor) PushCallStack(counter int) {
 	executor.callStack = append(executor.callStack, counter)
 }
 
 func (executor *Executor) PopCallStack() (int, error) {
 	if len(executor.callStack) == 0 {
-		return 0, runtimeError(executor, "call stack is empry")
+		return 0, runtimeError(executor, CallStackUnderflow, "call stack is empty")
 	}
 
 	counter := executor.callStack[len(executor.callStack)-1]
 	executor.callStack = executor.callStack[:len(executor.callStack)-1]
 	return counter, nil
 }
+
+func (executor *Executor) currentInstruction() Instruction {
+	if executor.programCounter < 0 || executor.programCounter >= len(executor.Instructions) {
+		return nil
+	}
+	return executor.Instructions[executor.programCounter]
+}
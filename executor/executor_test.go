@@ -0,0 +1,193 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun(t *testing.T) {
+	output := &bytes.Buffer{}
+	executor := &Executor{
+		Instructions: []Instruction{
+			Push{Value: NewNumber(0)},
+			Getc{},
+			Push{Value: NewNumber(0)},
+			Retrieve{},
+			Putc{},
+			Push{Value: NewNumber(0)},
+			Getn{},
+			Push{Value: NewNumber(0)},
+			Retrieve{},
+			Putn{},
+		},
+		Input:  strings.NewReader("x12\n"),
+		Output: output,
+	}
+
+	err := executor.Run()
+
+	assert.Nil(t, err)
+	assert.Equal(t, "x12", output.String())
+}
+
+func TestRunWithLimits(t *testing.T) {
+	loop := []Instruction{
+		MarkLabel{Label: "F"},
+		Push{Value: NewNumber(1)},
+		JumpLabel{Label: "F", Target: 0},
+	}
+
+	tests := []struct {
+		name         string
+		instructions []Instruction
+		limits       Limits
+		kind         RuntimeErrorKind
+	}{
+		{
+			name:         "steps",
+			instructions: []Instruction{MarkLabel{Label: "F"}, JumpLabel{Label: "F", Target: 0}},
+			limits:       Limits{MaxSteps: 100},
+			kind:         StepLimitExceeded,
+		},
+		{
+			name:         "stack",
+			instructions: loop,
+			limits:       Limits{MaxStack: 10},
+			kind:         StackLimitExceeded,
+		},
+		{
+			name:         "call depth",
+			instructions: []Instruction{MarkLabel{Label: "F"}, CallSubroutine{Label: "F", Target: 0}},
+			limits:       Limits{MaxCallDepth: 10},
+			kind:         CallDepthLimitExceeded,
+		},
+		{
+			name: "heap",
+			instructions: []Instruction{
+				Push{Value: NewNumber(0)},
+				MarkLabel{Label: "F"},
+				Push{Value: NewNumber(1)},
+				Addition{},
+				Duplicate{},
+				Duplicate{},
+				Store{},
+				JumpLabel{Label: "F", Target: 1},
+			},
+			limits: Limits{MaxHeap: 10},
+			kind:   HeapLimitExceeded,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			executor := &Executor{
+				Instructions: test.instructions,
+				Input:        strings.NewReader(""),
+				Output:       &bytes.Buffer{},
+				Limits:       test.limits,
+			}
+
+			err := executor.Run()
+
+			var runtimeErr *RuntimeError
+			assert.True(t, errors.As(err, &runtimeErr))
+			assert.Equal(t, test.kind, runtimeErr.Kind)
+		})
+	}
+}
+
+func TestRunFallsBackToExecute(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		kind  RuntimeErrorKind
+		stack []Number
+		steps int
+	}{
+		{name: "input is not numeric", input: "x\n", kind: InvalidInput, stack: []Number{NewNumber(6), NewNumber(7)}, steps: 5},
+		{name: "divide by zero", input: "0\n", kind: DivideByZero, stack: []Number{NewNumber(6)}, steps: 8},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			executor := &Executor{
+				Instructions: []Instruction{
+					Push{Value: NewNumber(6)},
+					Push{Value: NewNumber(7)},
+					Putn{},
+					Push{Value: NewNumber(7)},
+					Getn{},
+					Push{Value: NewNumber(7)},
+					Retrieve{},
+					Division{},
+				},
+				Input:  strings.NewReader(test.input),
+				Output: &bytes.Buffer{},
+			}
+
+			err := executor.Run()
+
+			var runtimeErr *RuntimeError
+			assert.True(t, errors.As(err, &runtimeErr))
+			assert.Equal(t, test.kind, runtimeErr.Kind)
+			assert.Equal(t, test.stack, executor.stack)
+			assert.Equal(t, test.steps, executor.steps)
+			assert.Equal(t, "7", executor.Output.(*bytes.Buffer).String())
+		})
+	}
+}
+
+func TestRunContext(t *testing.T) {
+	executor := &Executor{
+		Instructions: []Instruction{
+			MarkLabel{Label: "F"},
+			JumpLabel{Label: "F", Target: 0},
+		},
+		Input:  strings.NewReader(""),
+		Output: &bytes.Buffer{},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := executor.RunContext(ctx)
+
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+
+	var runtimeErr *RuntimeError
+	assert.True(t, errors.As(err, &runtimeErr))
+	assert.Equal(t, Canceled, runtimeErr.Kind)
+	assert.Contains(t, err.Error(), "context deadline exceeded at program counter")
+}
+
+// BenchmarkRun counts down from 1000000 in a loop.
+func BenchmarkRun(b *testing.B) {
+	executor := &Executor{
+		Instructions: []Instruction{
+			Push{Value: NewNumber(1000000)},
+			MarkLabel{Label: "F"},
+			Push{Value: NewNumber(1)},
+			Subtraction{},
+			Duplicate{},
+			JumpLabelWhenZero{Label: "L", Target: 7},
+			JumpLabel{Label: "F", Target: 1},
+			MarkLabel{Label: "L"},
+			EndProgram{},
+		},
+		Input:  strings.NewReader(""),
+		Output: &bytes.Buffer{},
+	}
+
+	for i := 0; i < b.N; i++ {
+		executor.stack = nil
+		if err := executor.Run(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
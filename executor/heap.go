@@ -0,0 +1,71 @@
+package executor
+
+import (
+	"maps"
+	"sort"
+)
+
+// heap keeps the cells at int addresses apart from the cells at big
+// addresses. Hashing an int is much cheaper than hashing a Number, and only
+// big mode stores at addresses which do not fit in an int.
+type heap struct {
+	small map[int]Number
+	big   map[string]Number
+}
+
+func newHeap() heap {
+	return heap{small: map[int]Number{}}
+}
+
+func (h heap) get(address Number) (Number, bool) {
+	if address.IsInt() {
+		value, ok := h.small[address.small]
+		return value, ok
+	}
+
+	value, ok := h.big[address.big]
+	return value, ok
+}
+
+func (h *heap) set(address Number, value Number) {
+	if address.IsInt() {
+		h.small[address.small] = value
+		return
+	}
+
+	if h.big == nil {
+		h.big = map[string]Number{}
+	}
+	h.big[address.big] = value
+}
+
+func (h heap) delete(address Number) {
+	if address.IsInt() {
+		delete(h.small, address.small)
+		return
+	}
+	delete(h.big, address.big)
+}
+
+func (h heap) len() int {
+	return len(h.small) + len(h.big)
+}
+
+func (h heap) clone() heap {
+	return heap{small: maps.Clone(h.small), big: maps.Clone(h.big)}
+}
+
+// cells returns the stored cells in order of address.
+func (h heap) cells() []HeapCell {
+	cells := []HeapCell{}
+	for address, value := range h.small {
+		cells = append(cells, HeapCell{Address: NewNumber(address), Value: value})
+	}
+	for address, value := range h.big {
+		cells = append(cells, HeapCell{Address: Number{big: address}, Value: value})
+	}
+	sort.Slice(cells, func(i, j int) bool {
+		return cells[i].Address.Cmp(cells[j].Address) < 0
+	})
+	return cells
+}
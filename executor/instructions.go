@@ -2,7 +2,7 @@ package executor
 
 import (
 	"fmt"
-	"strconv"
+	"io"
 
 	"github.com/simomu-github/fflt_lang/lexer"
 )
@@ -10,35 +10,76 @@ import (
 type Instruction interface {
 	Execute(executor *Executor) error
 	Disassenble() string
+	Token() lexer.Token
+	Span() lexer.Span
+}
+
+// Source is the source an instruction was parsed from. Instructions embed
+// it by pointer so that they stay cheap to copy on every step, and a nil
+// Source stands for an instruction which was not parsed, e.g. in tests.
+type Source struct {
+	token lexer.Token
+	span  lexer.Span
+}
+
+func NewSource(token lexer.Token, span lexer.Span) *Source {
+	return &Source{token: token, span: span}
+}
+
+// Token returns the command token the instruction was parsed from.
+func (s *Source) Token() lexer.Token {
+	if s == nil {
+		return lexer.Token{}
+	}
+	return s.token
+}
+
+// Span returns the source of the instruction, which covers the IMP, the
+// command and the parameter.
+func (s *Source) Span() lexer.Span {
+	if s == nil {
+		return lexer.Span{}
+	}
+	return s.span
 }
 
 type Push struct {
-	Value int
+	*Source
+	Value Number
 }
 
 func (p Push) Execute(executor *Executor) error {
-	executor.stack = append(executor.stack, p.Value)
+	value, ok := executor.fit(p.Value)
+	if !ok {
+		return runtimeErrorWithToken(executor, p.Token(), IntegerOverflow, "integer overflow")
+	}
+
+	if err := executor.checkStackLimit(p.Token()); err != nil {
+		return err
+	}
+
+	executor.stack = append(executor.stack, value)
 
 	return nil
 }
 
 func (p Push) Disassenble() string {
-	return fmt.Sprintf("PUSH           %d", p.Value)
+	return "PUSH           " + p.Value.String()
 }
 
 type Swap struct {
-	Token lexer.Token
+	*Source
 }
 
 func (s Swap) Execute(executor *Executor) error {
 	a, errA := executor.Pop()
 	if errA != nil {
-		return runtimeErrorWithToken(executor, s.Token, "stack is empty")
+		return runtimeErrorWithToken(executor, s.Token(), StackUnderflow, "stack is empty")
 	}
 
 	b, errB := executor.Pop()
 	if errB != nil {
-		return runtimeErrorWithToken(executor, s.Token, "stack is empty")
+		return runtimeErrorWithToken(executor, s.Token(), StackUnderflow, "stack is empty")
 	}
 
 	executor.Push(a)
@@ -51,13 +92,17 @@ func (s Swap) Disassenble() string {
 }
 
 type Duplicate struct {
-	Token lexer.Token
+	*Source
 }
 
 func (d Duplicate) Execute(executor *Executor) error {
+	if err := executor.checkStackLimit(d.Token()); err != nil {
+		return err
+	}
+
 	a, err := executor.Pop()
 	if err != nil {
-		return runtimeErrorWithToken(executor, d.Token, "stack is empty")
+		return runtimeErrorWithToken(executor, d.Token(), StackUnderflow, "stack is empty")
 	}
 
 	executor.Push(a)
@@ -69,7 +114,9 @@ func (d Duplicate) Disassenble() string {
 	return "DUP"
 }
 
-type Discard struct{}
+type Discard struct {
+	*Source
+}
 
 func (d Discard) Execute(executor *Executor) error {
 	executor.Pop()
@@ -82,23 +129,28 @@ func (d Discard) Disassenble() string {
 }
 
 type Copy struct {
-	Token lexer.Token
+	*Source
 	Value int
 }
 
 func (c Copy) Execute(executor *Executor) error {
 	if c.Value < 0 {
-		return runtimeErrorWithToken(executor, c.Token, "Copy parameter must be a positive number")
+		return runtimeErrorWithToken(executor, c.Token(), InvalidParameter, "Copy parameter must be a positive number")
 	}
 
 	if len(executor.stack) <= c.Value {
 		return runtimeErrorWithToken(
 			executor,
-			c.Token,
+			c.Token(),
+			StackOutOfRange,
 			fmt.Sprintf("copy stack[%d] is out of index. stack length: %d", c.Value, len(executor.stack)),
 		)
 	}
 
+	if err := executor.checkStackLimit(c.Token()); err != nil {
+		return err
+	}
+
 	value := executor.stack[len(executor.stack)-1-c.Value]
 	executor.Push(value)
 	return nil
@@ -109,19 +161,20 @@ func (c Copy) Disassenble() string {
 }
 
 type Slide struct {
-	Token lexer.Token
+	*Source
 	Value int
 }
 
 func (s Slide) Execute(executor *Executor) error {
 	if s.Value < 0 {
-		return runtimeErrorWithToken(executor, s.Token, "Slide parameter must be a positive number")
+		return runtimeErrorWithToken(executor, s.Token(), InvalidParameter, "Slide parameter must be a positive number")
 	}
 
 	if len(executor.stack) <= s.Value {
 		return runtimeErrorWithToken(
 			executor,
-			s.Token,
+			s.Token(),
+			StackOutOfRange,
 			fmt.Sprintf("slide length (%d) is out of stack length (%d)", s.Value, len(executor.stack)),
 		)
 	}
@@ -138,21 +191,26 @@ func (s Slide) Disassenble() string {
 }
 
 type Addition struct {
-	Token lexer.Token
+	*Source
 }
 
 func (a Addition) Execute(executor *Executor) error {
 	rhs, errRhs := executor.Pop()
 	if errRhs != nil {
-		return runtimeErrorWithToken(executor, a.Token, "stack is empty")
+		return runtimeErrorWithToken(executor, a.Token(), StackUnderflow, "stack is empty")
 	}
 
 	lhs, errLhs := executor.Pop()
 	if errLhs != nil {
-		return runtimeErrorWithToken(executor, a.Token, "stack is empty")
+		return runtimeErrorWithToken(executor, a.Token(), StackUnderflow, "stack is empty")
 	}
 
-	executor.Push(lhs + rhs)
+	value, ok := executor.add(lhs, rhs)
+	if !ok {
+		return runtimeErrorWithToken(executor, a.Token(), IntegerOverflow, "integer overflow")
+	}
+
+	executor.Push(value)
 
 	return nil
 }
@@ -162,21 +220,26 @@ func (a Addition) Disassenble() string {
 }
 
 type Subtraction struct {
-	Token lexer.Token
+	*Source
 }
 
 func (s Subtraction) Execute(executor *Executor) error {
 	rhs, errRhs := executor.Pop()
 	if errRhs != nil {
-		return runtimeErrorWithToken(executor, s.Token, "stack is empty")
+		return runtimeErrorWithToken(executor, s.Token(), StackUnderflow, "stack is empty")
 	}
 
 	lhs, errLhs := executor.Pop()
 	if errLhs != nil {
-		return runtimeErrorWithToken(executor, s.Token, "stack is empty")
+		return runtimeErrorWithToken(executor, s.Token(), StackUnderflow, "stack is empty")
 	}
 
-	executor.Push(lhs - rhs)
+	value, ok := executor.subtract(lhs, rhs)
+	if !ok {
+		return runtimeErrorWithToken(executor, s.Token(), IntegerOverflow, "integer overflow")
+	}
+
+	executor.Push(value)
 
 	return nil
 }
@@ -186,21 +249,26 @@ func (s Subtraction) Disassenble() string {
 }
 
 type Multiplication struct {
-	Token lexer.Token
+	*Source
 }
 
 func (m Multiplication) Execute(executor *Executor) error {
 	rhs, errRhs := executor.Pop()
 	if errRhs != nil {
-		return runtimeErrorWithToken(executor, m.Token, "stack is empty")
+		return runtimeErrorWithToken(executor, m.Token(), StackUnderflow, "stack is empty")
 	}
 
 	lhs, errLhs := executor.Pop()
 	if errLhs != nil {
-		return runtimeErrorWithToken(executor, m.Token, "stack is empty")
+		return runtimeErrorWithToken(executor, m.Token(), StackUnderflow, "stack is empty")
 	}
 
-	executor.Push(lhs * rhs)
+	value, ok := executor.multiply(lhs, rhs)
+	if !ok {
+		return runtimeErrorWithToken(executor, m.Token(), IntegerOverflow, "integer overflow")
+	}
+
+	executor.Push(value)
 
 	return nil
 }
@@ -210,25 +278,30 @@ func (m Multiplication) Disassenble() string {
 }
 
 type Division struct {
-	Token lexer.Token
+	*Source
 }
 
 func (d Division) Execute(executor *Executor) error {
 	rhs, errRhs := executor.Pop()
 	if errRhs != nil {
-		return runtimeErrorWithToken(executor, d.Token, "stack is empty")
+		return runtimeErrorWithToken(executor, d.Token(), StackUnderflow, "stack is empty")
 	}
 
-	if rhs == 0 {
-		return runtimeErrorWithToken(executor, d.Token, "integer divide by zero")
+	if rhs.Sign() == 0 {
+		return runtimeErrorWithToken(executor, d.Token(), DivideByZero, "integer divide by zero")
 	}
 
 	lhs, errLhs := executor.Pop()
 	if errLhs != nil {
-		return runtimeErrorWithToken(executor, d.Token, "stack is empty")
+		return runtimeErrorWithToken(executor, d.Token(), StackUnderflow, "stack is empty")
 	}
 
-	executor.Push(lhs / rhs)
+	value, ok := executor.divide(lhs, rhs)
+	if !ok {
+		return runtimeErrorWithToken(executor, d.Token(), IntegerOverflow, "integer overflow")
+	}
+
+	executor.Push(value)
 
 	return nil
 }
@@ -238,25 +311,25 @@ func (d Division) Disassenble() string {
 }
 
 type Modulo struct {
-	Token lexer.Token
+	*Source
 }
 
 func (m Modulo) Execute(executor *Executor) error {
 	rhs, errRhs := executor.Pop()
 	if errRhs != nil {
-		return runtimeErrorWithToken(executor, m.Token, "stack is empty")
+		return runtimeErrorWithToken(executor, m.Token(), StackUnderflow, "stack is empty")
 	}
 
-	if rhs == 0 {
-		return runtimeErrorWithToken(executor, m.Token, "integer divide by zero")
+	if rhs.Sign() == 0 {
+		return runtimeErrorWithToken(executor, m.Token(), DivideByZero, "integer divide by zero")
 	}
 
 	lhs, errLhs := executor.Pop()
 	if errLhs != nil {
-		return runtimeErrorWithToken(executor, m.Token, "stack is empty")
+		return runtimeErrorWithToken(executor, m.Token(), StackUnderflow, "stack is empty")
 	}
 
-	executor.Push(lhs % rhs)
+	executor.Push(executor.modulo(lhs, rhs))
 
 	return nil
 }
@@ -266,23 +339,33 @@ func (m Modulo) Disassenble() string {
 }
 
 type Getc struct {
-	Token lexer.Token
+	*Source
 }
 
 func (g Getc) Execute(executor *Executor) error {
-	text := executor.Input()
+	char, errRead := executor.readRune()
+	return g.store(executor, char, errRead)
+}
 
-	if len(text) == 0 {
-		return runtimeError(executor, "input is empty")
+// store finishes GETC with what readRune returned.
+func (g Getc) store(executor *Executor, char rune, errRead error) error {
+	if errRead == io.EOF && executor.eofIsError() {
+		return runtimeErrorWithToken(executor, g.Token(), InputEmpty, "input is empty")
+	}
+	if errRead != nil && errRead != io.EOF {
+		return runtimeErrorWithToken(executor, g.Token(), IOError, errRead.Error())
 	}
 
 	address, err := executor.Pop()
 	if err != nil {
-		return runtimeErrorWithToken(executor, g.Token, "stack is empty")
+		return runtimeErrorWithToken(executor, g.Token(), StackUnderflow, "stack is empty")
 	}
 
-	executor.heap[address] = int([]rune(text)[0])
-	return nil
+	if errRead == io.EOF {
+		return executor.storeEOF(g.Token(), address)
+	}
+
+	return executor.storeHeap(g.Token(), address, NewNumber(int(char)))
 }
 
 func (g Getc) Disassenble() string {
@@ -290,23 +373,48 @@ func (g Getc) Disassenble() string {
 }
 
 type Getn struct {
-	Token lexer.Token
+	*Source
 }
 
 func (g Getn) Execute(executor *Executor) error {
-	text := executor.Input()
-	n, err := strconv.Atoi(text)
-	if err != nil {
-		return runtimeError(executor, "input character is not numeric")
+	text, errRead := executor.readLine()
+	return g.store(executor, text, errRead)
+}
+
+// store finishes GETN with what readLine returned.
+func (g Getn) store(executor *Executor, text string, errRead error) error {
+	if errRead == io.EOF && executor.eofIsError() {
+		return runtimeErrorWithToken(executor, g.Token(), InputEmpty, "input is empty")
+	}
+	if errRead != nil && errRead != io.EOF {
+		return runtimeErrorWithToken(executor, g.Token(), IOError, errRead.Error())
+	}
+
+	n, ok := ParseNumber(text)
+	if !ok && errRead == nil {
+		return runtimeErrorWithToken(executor, g.Token(), InvalidInput, "input character is not numeric")
+	}
+
+	// only big mode reads numbers which do not fit in an int
+	if ok && !n.IsInt() {
+		switch executor.mode() {
+		case ArithmeticTrap:
+			return runtimeErrorWithToken(executor, g.Token(), IntegerOverflow, "integer overflow")
+		case ArithmeticWrap:
+			return runtimeErrorWithToken(executor, g.Token(), InvalidInput, "input number is out of range")
+		}
 	}
 
 	address, err := executor.Pop()
 	if err != nil {
-		return runtimeErrorWithToken(executor, g.Token, "stack is empty")
+		return runtimeErrorWithToken(executor, g.Token(), StackUnderflow, "stack is empty")
 	}
 
-	executor.heap[address] = n
-	return nil
+	if errRead == io.EOF {
+		return executor.storeEOF(g.Token(), address)
+	}
+
+	return executor.storeHeap(g.Token(), address, n)
 }
 
 func (g Getn) Disassenble() string {
@@ -314,16 +422,18 @@ func (g Getn) Disassenble() string {
 }
 
 type Putc struct {
-	Token lexer.Token
+	*Source
 }
 
 func (p Putc) Execute(executor *Executor) error {
 	n, err := executor.Pop()
 	if err != nil {
-		return runtimeErrorWithToken(executor, p.Token, "stack is empty")
+		return runtimeErrorWithToken(executor, p.Token(), StackUnderflow, "stack is empty")
 	}
 
-	executor.Output(fmt.Sprintf("%c", n))
+	if err := executor.write(fmt.Sprintf("%c", n.Int())); err != nil {
+		return runtimeErrorWithToken(executor, p.Token(), IOError, err.Error())
+	}
 
 	return nil
 }
@@ -333,16 +443,18 @@ func (p Putc) Disassenble() string {
 }
 
 type Putn struct {
-	Token lexer.Token
+	*Source
 }
 
 func (p Putn) Execute(executor *Executor) error {
 	n, err := executor.Pop()
 	if err != nil {
-		return runtimeErrorWithToken(executor, p.Token, "stack is empty")
+		return runtimeErrorWithToken(executor, p.Token(), StackUnderflow, "stack is empty")
 	}
 
-	executor.Output(fmt.Sprintf("%d", n))
+	if err := executor.write(n.String()); err != nil {
+		return runtimeErrorWithToken(executor, p.Token(), IOError, err.Error())
+	}
 
 	return nil
 }
@@ -352,22 +464,21 @@ func (p Putn) Disassenble() string {
 }
 
 type Store struct {
-	Token lexer.Token
+	*Source
 }
 
 func (s Store) Execute(executor *Executor) error {
 	value, errValue := executor.Pop()
 	if errValue != nil {
-		return runtimeErrorWithToken(executor, s.Token, "stack is empty")
+		return runtimeErrorWithToken(executor, s.Token(), StackUnderflow, "stack is empty")
 	}
 
 	address, errAddress := executor.Pop()
 	if errAddress != nil {
-		return runtimeErrorWithToken(executor, s.Token, "stack is empty")
+		return runtimeErrorWithToken(executor, s.Token(), StackUnderflow, "stack is empty")
 	}
 
-	executor.heap[address] = value
-	return nil
+	return executor.storeHeap(s.Token(), address, value)
 }
 
 func (s Store) Disassenble() string {
@@ -375,18 +486,18 @@ func (s Store) Disassenble() string {
 }
 
 type Retrieve struct {
-	Token lexer.Token
+	*Source
 }
 
 func (r Retrieve) Execute(executor *Executor) error {
 	address, err := executor.Pop()
 	if err != nil {
-		return runtimeErrorWithToken(executor, r.Token, "stack is empty")
+		return runtimeErrorWithToken(executor, r.Token(), StackUnderflow, "stack is empty")
 	}
 
-	value, ok := executor.heap[address]
-	if !ok {
-		return runtimeErrorWithToken(executor, r.Token, "invalid heap access")
+	value, err := executor.retrieveHeap(r.Token(), address)
+	if err != nil {
+		return err
 	}
 
 	executor.Push(value)
@@ -398,6 +509,7 @@ func (r Retrieve) Disassenble() string {
 }
 
 type MarkLabel struct {
+	*Source
 	Label string
 }
 
@@ -410,20 +522,20 @@ func (m MarkLabel) Disassenble() string {
 }
 
 type CallSubroutine struct {
-	Token lexer.Token
-	Label string
+	*Source
+	Label  string
+	Target int
 }
 
 func (c CallSubroutine) Execute(executor *Executor) error {
+	if err := executor.checkCallDepthLimit(c.Token()); err != nil {
+		return err
+	}
+
 	counter := executor.programCounter
 	executor.PushCallStack(counter)
 
-	newCounter, ok := executor.LabelMap[c.Label]
-	if !ok {
-		return runtimeErrorWithToken(executor, c.Token, fmt.Sprintf("label \"%s\" is not found", c.Label))
-	}
-
-	executor.programCounter = newCounter
+	executor.programCounter = c.Target
 	return nil
 }
 
@@ -432,13 +544,13 @@ func (c CallSubroutine) Disassenble() string {
 }
 
 type EndSubroutine struct {
-	Token lexer.Token
+	*Source
 }
 
 func (e EndSubroutine) Execute(executor *Executor) error {
 	counter, err := executor.PopCallStack()
 	if err != nil {
-		return runtimeErrorWithToken(executor, e.Token, "call stack is empty")
+		return runtimeErrorWithToken(executor, e.Token(), CallStackUnderflow, "call stack is empty")
 	}
 
 	executor.programCounter = counter
@@ -450,17 +562,13 @@ func (e EndSubroutine) Disassenble() string {
 }
 
 type JumpLabel struct {
-	Token lexer.Token
-	Label string
+	*Source
+	Label  string
+	Target int
 }
 
 func (j JumpLabel) Execute(executor *Executor) error {
-	newCounter, ok := executor.LabelMap[j.Label]
-	if !ok {
-		return runtimeErrorWithToken(executor, j.Token, fmt.Sprintf("label \"%s\" is not found", j.Label))
-	}
-
-	executor.programCounter = newCounter
+	executor.programCounter = j.Target
 	return nil
 }
 
@@ -469,26 +577,22 @@ func (j JumpLabel) Disassenble() string {
 }
 
 type JumpLabelWhenZero struct {
-	Token lexer.Token
-	Label string
+	*Source
+	Label  string
+	Target int
 }
 
 func (j JumpLabelWhenZero) Execute(executor *Executor) error {
 	value, err := executor.Pop()
 	if err != nil {
-		return runtimeErrorWithToken(executor, j.Token, "stack is empty")
+		return runtimeErrorWithToken(executor, j.Token(), StackUnderflow, "stack is empty")
 	}
 
-	if value != 0 {
+	if value.Sign() != 0 {
 		return nil
 	}
 
-	newCounter, ok := executor.LabelMap[j.Label]
-	if !ok {
-		return runtimeErrorWithToken(executor, j.Token, fmt.Sprintf("label \"%s\" is not found", j.Label))
-	}
-
-	executor.programCounter = newCounter
+	executor.programCounter = j.Target
 	return nil
 }
 
@@ -497,26 +601,22 @@ func (j JumpLabelWhenZero) Disassenble() string {
 }
 
 type JumpLabelWhenNegative struct {
-	Token lexer.Token
-	Label string
+	*Source
+	Label  string
+	Target int
 }
 
 func (j JumpLabelWhenNegative) Execute(executor *Executor) error {
 	value, err := executor.Pop()
 	if err != nil {
-		return runtimeErrorWithToken(executor, j.Token, "stack is empty")
+		return runtimeErrorWithToken(executor, j.Token(), StackUnderflow, "stack is empty")
 	}
 
-	if value >= 0 {
+	if value.Sign() >= 0 {
 		return nil
 	}
 
-	newCounter, ok := executor.LabelMap[j.Label]
-	if !ok {
-		return runtimeErrorWithToken(executor, j.Token, fmt.Sprintf("label \"%s\" is not found", j.Label))
-	}
-
-	executor.programCounter = newCounter
+	executor.programCounter = j.Target
 	return nil
 }
 
@@ -524,7 +624,9 @@ func (j JumpLabelWhenNegative) Disassenble() string {
 	return "JUMP_WHEN_NEGA " + j.Label
 }
 
-type EndProgram struct{}
+type EndProgram struct {
+	*Source
+}
 
 func (e EndProgram) Execute(executor *Executor) error {
 	executor.programCounter = len(executor.Instructions)
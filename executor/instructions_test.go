@@ -1,132 +1,148 @@
 package executor
 
 import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
 	"testing"
 
+	"github.com/simomu-github/fflt_lang/lexer"
 	"github.com/stretchr/testify/assert"
 )
 
 func newExecutor() *Executor {
 	return &Executor{
-		heap:           map[int]int{},
+		heap:           newHeap(),
 		programCounter: 0,
 	}
 }
 
-func newExecutorWithIOMock(input func() string, output func(string)) *Executor {
+func newExecutorWithIO(input io.Reader, output io.Writer) *Executor {
 	return &Executor{
 		Input:          input,
 		Output:         output,
-		heap:           map[int]int{},
+		heap:           newHeap(),
 		programCounter: 0,
 	}
 }
 
-type ioMock struct {
-	In  func() string
-	Out func(string)
+func numbers(values ...int) []Number {
+	result := []Number{}
+	for _, value := range values {
+		result = append(result, NewNumber(value))
+	}
+	return result
+}
+
+func heapOf(values map[int]int) heap {
+	result := newHeap()
+	for address, value := range values {
+		result.set(NewNumber(address), NewNumber(value))
+	}
+	return result
 }
 
 func TestPush(t *testing.T) {
 	executor := newExecutor()
 
-	push := Push{Value: 1}
+	push := Push{Value: NewNumber(1)}
 	push.Execute(executor)
 
-	assert.Equal(t, []int{1}, executor.stack)
+	assert.Equal(t, numbers(1), executor.stack)
 }
 
 func TestDuplicate(t *testing.T) {
 	executor := newExecutor()
-	executor.stack = []int{1}
+	executor.stack = numbers(1)
 
 	duplicate := Duplicate{}
 	duplicate.Execute(executor)
 
-	assert.Equal(t, []int{1, 1}, executor.stack)
+	assert.Equal(t, numbers(1, 1), executor.stack)
 }
 
 func TestSwap(t *testing.T) {
 	executor := newExecutor()
-	executor.stack = []int{1, 2}
+	executor.stack = numbers(1, 2)
 
 	swap := Swap{}
 	swap.Execute(executor)
 
-	assert.Equal(t, []int{2, 1}, executor.stack)
+	assert.Equal(t, numbers(2, 1), executor.stack)
 }
 
 func TestDiscard(t *testing.T) {
 	executor := newExecutor()
-	executor.stack = []int{1}
+	executor.stack = numbers(1)
 
 	discard := Discard{}
 	discard.Execute(executor)
 
-	assert.Equal(t, []int{}, executor.stack)
+	assert.Equal(t, numbers(), executor.stack)
 }
 
 func TestCopy(t *testing.T) {
 	executor := newExecutor()
-	executor.stack = []int{1, 2, 3}
+	executor.stack = numbers(1, 2, 3)
 
 	copy := Copy{Value: 1}
 	copy.Execute(executor)
 
-	assert.Equal(t, []int{1, 2, 3, 2}, executor.stack)
+	assert.Equal(t, numbers(1, 2, 3, 2), executor.stack)
 }
 
 func TestSlide(t *testing.T) {
 	executor := newExecutor()
-	executor.stack = []int{1, 2, 3, 4}
+	executor.stack = numbers(1, 2, 3, 4)
 
 	slide := Slide{Value: 2}
 	slide.Execute(executor)
 
-	assert.Equal(t, []int{1, 4}, executor.stack)
+	assert.Equal(t, numbers(1, 4), executor.stack)
 }
 
 func TestAddition(t *testing.T) {
 	executor := newExecutor()
-	executor.stack = []int{1, 2}
+	executor.stack = numbers(1, 2)
 
 	addition := Addition{}
 	addition.Execute(executor)
 
-	assert.Equal(t, []int{3}, executor.stack)
+	assert.Equal(t, numbers(3), executor.stack)
 }
 
 func TestSubtraction(t *testing.T) {
 	executor := newExecutor()
-	executor.stack = []int{2, 1}
+	executor.stack = numbers(2, 1)
 
 	subtraction := Subtraction{}
 	subtraction.Execute(executor)
 
-	assert.Equal(t, []int{1}, executor.stack)
+	assert.Equal(t, numbers(1), executor.stack)
 }
 
 func TestMultiplication(t *testing.T) {
 	executor := newExecutor()
-	executor.stack = []int{2, 2}
+	executor.stack = numbers(2, 2)
 
 	multiplication := Multiplication{}
 	multiplication.Execute(executor)
 
-	assert.Equal(t, []int{4}, executor.stack)
+	assert.Equal(t, numbers(4), executor.stack)
 }
 
 func TestDivision(t *testing.T) {
 	executor := newExecutor()
-	executor.stack = []int{4, 2}
+	executor.stack = numbers(4, 2)
 
 	divition := Division{}
 	divition.Execute(executor)
 
-	assert.Equal(t, []int{2}, executor.stack)
+	assert.Equal(t, numbers(2), executor.stack)
 
 	t.Run("when divide by zero", func(t *testing.T) {
-		executor.stack = []int{4, 0}
+		executor.stack = numbers(4, 0)
 
 		divition := Division{}
 		err := divition.Execute(executor)
@@ -136,15 +152,15 @@ func TestDivision(t *testing.T) {
 
 func TestModulo(t *testing.T) {
 	executor := newExecutor()
-	executor.stack = []int{5, 3}
+	executor.stack = numbers(5, 3)
 
 	modulo := Modulo{}
 	modulo.Execute(executor)
 
-	assert.Equal(t, []int{2}, executor.stack)
+	assert.Equal(t, numbers(2), executor.stack)
 
 	t.Run("when modulo by zero", func(t *testing.T) {
-		executor.stack = []int{5, 0}
+		executor.stack = numbers(5, 0)
 
 		divition := Modulo{}
 		err := divition.Execute(executor)
@@ -152,101 +168,356 @@ func TestModulo(t *testing.T) {
 	})
 }
 
+func TestArithmeticInBigMode(t *testing.T) {
+	executor := newExecutor()
+	executor.Arithmetic = ArithmeticBig
+
+	maxInt, _ := ParseNumber("9223372036854775807")
+	executor.stack = []Number{maxInt, NewNumber(1)}
+
+	addition := Addition{}
+	addition.Execute(executor)
+
+	sum, _ := ParseNumber("9223372036854775808")
+	assert.Equal(t, []Number{sum}, executor.stack)
+
+	executor.stack = append(executor.stack, sum)
+
+	multiplication := Multiplication{}
+	multiplication.Execute(executor)
+
+	product, _ := ParseNumber("85070591730234615865843651857942052864")
+	assert.Equal(t, []Number{product}, executor.stack)
+
+	executor.stack = append(executor.stack, sum)
+
+	division := Division{}
+	division.Execute(executor)
+
+	assert.Equal(t, []Number{sum}, executor.stack)
+
+	executor.stack = append(executor.stack, maxInt)
+
+	subtraction := Subtraction{}
+	subtraction.Execute(executor)
+
+	assert.Equal(t, numbers(1), executor.stack)
+}
+
+func TestArithmeticInWrapMode(t *testing.T) {
+	executor := newExecutor()
+
+	maxInt, _ := ParseNumber("9223372036854775807")
+	minInt, _ := ParseNumber("-9223372036854775808")
+	executor.stack = []Number{maxInt, NewNumber(1)}
+
+	addition := Addition{}
+	addition.Execute(executor)
+
+	assert.Equal(t, []Number{minInt}, executor.stack)
+}
+
+func TestArithmeticInTrapMode(t *testing.T) {
+	maxInt, _ := ParseNumber("9223372036854775807")
+	minInt, _ := ParseNumber("-9223372036854775808")
+
+	tests := []struct {
+		name        string
+		stack       []Number
+		instruction Instruction
+	}{
+		{name: "addition", stack: []Number{maxInt, NewNumber(1)}, instruction: Addition{}},
+		{name: "subtraction", stack: []Number{minInt, NewNumber(1)}, instruction: Subtraction{}},
+		{name: "multiplication", stack: []Number{maxInt, NewNumber(2)}, instruction: Multiplication{}},
+		{name: "division", stack: []Number{minInt, NewNumber(-1)}, instruction: Division{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			executor := newExecutor()
+			executor.Arithmetic = ArithmeticTrap
+			executor.stack = test.stack
+
+			err := test.instruction.Execute(executor)
+
+			var runtimeErr *RuntimeError
+			assert.True(t, errors.As(err, &runtimeErr))
+			assert.Equal(t, IntegerOverflow, runtimeErr.Kind)
+		})
+	}
+
+	t.Run("when number literal overflows", func(t *testing.T) {
+		value, _ := ParseNumber("9223372036854775808")
+		token := lexer.Token{Type: lexer.Push, Literal: "FF", Line: 3, Column: 2}
+
+		executor := newExecutor()
+		executor.Arithmetic = ArithmeticTrap
+
+		push := Push{Source: NewSource(token, lexer.Span{}), Value: value}
+		err := push.Execute(executor)
+
+		var runtimeErr *RuntimeError
+		assert.True(t, errors.As(err, &runtimeErr))
+		assert.Equal(t, IntegerOverflow, runtimeErr.Kind)
+		assert.Equal(t, 3, runtimeErr.Line)
+	})
+
+	t.Run("when result does not overflow", func(t *testing.T) {
+		executor := newExecutor()
+		executor.Arithmetic = ArithmeticTrap
+		executor.stack = []Number{maxInt, NewNumber(-1)}
+
+		addition := Addition{}
+		err := addition.Execute(executor)
+
+		assert.Nil(t, err)
+		assert.Equal(t, numbers(9223372036854775806), executor.stack)
+	})
+}
+
 func TestStore(t *testing.T) {
 	executor := newExecutor()
-	executor.stack = []int{1, 2}
+	executor.stack = numbers(1, 2)
 
 	store := Store{}
 	store.Execute(executor)
 
-	assert.Equal(t, map[int]int{1: 2}, executor.heap)
-	assert.Equal(t, []int{}, executor.stack)
+	assert.Equal(t, heapOf(map[int]int{1: 2}), executor.heap)
+	assert.Equal(t, numbers(), executor.stack)
 }
 
 func TestRetrieve(t *testing.T) {
 	executor := newExecutor()
-	executor.stack = []int{1}
-	executor.heap = map[int]int{1: 2}
+	executor.stack = numbers(1)
+	executor.heap = heapOf(map[int]int{1: 2})
 
 	retrieve := Retrieve{}
 	retrieve.Execute(executor)
 
-	assert.Equal(t, []int{2}, executor.stack)
+	assert.Equal(t, numbers(2), executor.stack)
+}
+
+func TestOnHeapAccess(t *testing.T) {
+	executor := newExecutorWithIO(strings.NewReader("a"), &bytes.Buffer{})
+	accesses := []HeapAccess{}
+	executor.OnHeapAccess = func(access HeapAccess) {
+		accesses = append(accesses, access)
+	}
+
+	executor.stack = numbers(1, 2)
+	Store{}.Execute(executor)
+	executor.stack = numbers(1)
+	Retrieve{}.Execute(executor)
+	executor.stack = numbers(1)
+	Getc{}.Execute(executor)
+
+	assert.Equal(t, []HeapAccess{
+		{Kind: HeapWrite, Address: NewNumber(1), New: NewNumber(2)},
+		{Kind: HeapRead, Address: NewNumber(1), Old: NewNumber(2), New: NewNumber(2), Existed: true},
+		{Kind: HeapWrite, Address: NewNumber(1), Old: NewNumber(2), New: NewNumber(97), Existed: true},
+	}, accesses)
 }
 
 func TestPutc(t *testing.T) {
-	executor := newExecutorWithIOMock(
-		func() string { return "" },
-		func(str string) {
-			assert.Equal(t, "a", str)
-		},
-	)
-	executor.stack = []int{97}
+	output := &bytes.Buffer{}
+	executor := newExecutorWithIO(strings.NewReader(""), output)
+	executor.stack = numbers(97)
 
 	putc := Putc{}
 	putc.Execute(executor)
 
-	assert.Equal(t, []int{}, executor.stack)
+	assert.Equal(t, numbers(), executor.stack)
+	assert.Equal(t, "", output.String())
+
+	executor.Flush()
+
+	assert.Equal(t, "a", output.String())
 }
 
 func TestPutn(t *testing.T) {
-	executor := newExecutorWithIOMock(
-		func() string { return "" },
-		func(str string) {
-			assert.Equal(t, "100", str)
-		},
-	)
-	executor.stack = []int{100}
+	output := &bytes.Buffer{}
+	executor := newExecutorWithIO(strings.NewReader(""), output)
+	executor.stack = numbers(100)
 
 	putn := Putn{}
 	putn.Execute(executor)
+	executor.Flush()
 
-	assert.Equal(t, []int{}, executor.stack)
+	assert.Equal(t, numbers(), executor.stack)
+	assert.Equal(t, "100", output.String())
 }
 
 func TestGetc(t *testing.T) {
-	inputChar := "a"
-	executor := newExecutorWithIOMock(
-		func() string {
-			return inputChar
-		},
-		func(_ string) {},
-	)
-	executor.stack = []int{1}
+	executor := newExecutorWithIO(strings.NewReader("a\nあ"), &bytes.Buffer{})
+	executor.stack = numbers(3, 2, 1)
 
 	getc := Getc{}
 	getc.Execute(executor)
 
-	assert.Equal(t, []int{}, executor.stack)
-	assert.Equal(t, map[int]int{1: 97}, executor.heap)
+	assert.Equal(t, numbers(3, 2), executor.stack)
+	assert.Equal(t, heapOf(map[int]int{1: 97}), executor.heap)
+
+	getc.Execute(executor)
+	getc.Execute(executor)
+
+	assert.Equal(t, heapOf(map[int]int{1: 97, 2: 10, 3: 12354}), executor.heap)
+
+	t.Run("when input is empty", func(t *testing.T) {
+		executor.stack = numbers(4)
+
+		err := getc.Execute(executor)
+
+		var runtimeErr *RuntimeError
+		assert.True(t, errors.As(err, &runtimeErr))
+		assert.Equal(t, InputEmpty, runtimeErr.Kind)
+	})
 }
 
 func TestGetn(t *testing.T) {
-	inputChar := "100"
-	executor := newExecutorWithIOMock(
-		func() string {
-			return inputChar
-		},
-		func(_ string) {},
-	)
-	executor.stack = []int{1}
-
-	getc := Getn{}
+	executor := newExecutorWithIO(strings.NewReader("100\n-2"), &bytes.Buffer{})
+	executor.stack = numbers(2, 1)
+
+	getn := Getn{}
+	getn.Execute(executor)
+
+	assert.Equal(t, numbers(2), executor.stack)
+	assert.Equal(t, heapOf(map[int]int{1: 100}), executor.heap)
+
+	getn.Execute(executor)
+
+	assert.Equal(t, heapOf(map[int]int{1: 100, 2: -2}), executor.heap)
+}
+
+func TestInputErrorPosition(t *testing.T) {
+	token := lexer.Token{Type: lexer.Getn, Literal: "LTLL", Line: 2, Column: 5}
+	executor := newExecutorWithIO(strings.NewReader("x\n"), &bytes.Buffer{})
+	executor.Filename = "a.fflt"
+	executor.stack = numbers(1, 1)
+
+	err := Getn{Source: NewSource(token, lexer.Span{})}.Execute(executor)
+	assert.EqualError(t, err, "Runtime error: input character is not numeric at a.fflt:2:5")
+
+	err = Getc{Source: NewSource(token, lexer.Span{})}.Execute(executor)
+	assert.EqualError(t, err, "Runtime error: input is empty at a.fflt:2:5")
+}
+
+func TestEOFPolicy(t *testing.T) {
+	tests := []struct {
+		policy   EOFPolicy
+		expected heap
+	}{
+		{policy: EOFNegativeOne, expected: heapOf(map[int]int{1: -1, 2: -1})},
+		{policy: EOFZero, expected: heapOf(map[int]int{1: 0, 2: 0})},
+		{policy: EOFUnchanged, expected: heapOf(map[int]int{1: 5, 2: 5})},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.policy), func(t *testing.T) {
+			executor := newExecutorWithIO(strings.NewReader(""), &bytes.Buffer{})
+			executor.EOF = test.policy
+			executor.heap = heapOf(map[int]int{1: 5, 2: 5})
+			executor.stack = numbers(2, 1)
+
+			getc := Getc{}
+			assert.Nil(t, getc.Execute(executor))
+
+			getn := Getn{}
+			assert.Nil(t, getn.Execute(executor))
+
+			assert.Equal(t, numbers(), executor.stack)
+			assert.Equal(t, test.expected, executor.heap)
+		})
+	}
+
+	t.Run("error", func(t *testing.T) {
+		executor := newExecutorWithIO(strings.NewReader(""), &bytes.Buffer{})
+		executor.EOF = EOFError
+		executor.stack = numbers(1)
+
+		getn := Getn{}
+		err := getn.Execute(executor)
+
+		var runtimeErr *RuntimeError
+		assert.True(t, errors.As(err, &runtimeErr))
+		assert.Equal(t, InputEmpty, runtimeErr.Kind)
+	})
+}
+
+func TestFlushOutputBeforeInput(t *testing.T) {
+	output := &bytes.Buffer{}
+	executor := newExecutorWithIO(strings.NewReader("a"), output)
+	executor.stack = numbers(1, 62)
+
+	putc := Putc{}
+	putc.Execute(executor)
+
+	getc := Getc{}
 	getc.Execute(executor)
 
-	assert.Equal(t, []int{}, executor.stack)
-	assert.Equal(t, map[int]int{1: 100}, executor.heap)
+	assert.Equal(t, ">", output.String())
+}
+
+func TestPushBigNumber(t *testing.T) {
+	value, _ := ParseNumber("18446744073709551617")
+
+	executor := newExecutor()
+	push := Push{Value: value}
+	push.Execute(executor)
+
+	assert.Equal(t, numbers(1), executor.stack)
+
+	executor = newExecutor()
+	executor.Arithmetic = ArithmeticBig
+	push.Execute(executor)
+
+	assert.Equal(t, []Number{value}, executor.stack)
+}
+
+func TestGetnBigNumber(t *testing.T) {
+	executor := newExecutorWithIO(strings.NewReader("123456789012345678901234567890\n"), &bytes.Buffer{})
+	executor.Arithmetic = ArithmeticBig
+	executor.stack = numbers(1)
+
+	getn := Getn{}
+	getn.Execute(executor)
+
+	value, _ := ParseNumber("123456789012345678901234567890")
+	assert.Equal(t, []HeapCell{{Address: NewNumber(1), Value: value}}, executor.heap.cells())
+}
+
+func TestGetnRejectsBigNumberUnlessBigMode(t *testing.T) {
+	tests := []struct {
+		mode    ArithmeticMode
+		kind    RuntimeErrorKind
+		message string
+	}{
+		{mode: ArithmeticWrap, kind: InvalidInput, message: "input number is out of range"},
+		{mode: ArithmeticTrap, kind: IntegerOverflow, message: "integer overflow"},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.mode), func(t *testing.T) {
+			executor := newExecutorWithIO(strings.NewReader("123456789012345678901234567890\n"), &bytes.Buffer{})
+			executor.Arithmetic = test.mode
+			executor.stack = numbers(1)
+
+			err := Getn{}.Execute(executor)
+
+			var runtimeErr *RuntimeError
+			assert.True(t, errors.As(err, &runtimeErr))
+			assert.Equal(t, test.kind, runtimeErr.Kind)
+			assert.Equal(t, test.message, runtimeErr.Message)
+			assert.Equal(t, 0, executor.heap.len())
+		})
+	}
 }
 
 func TestCallSubroutine(t *testing.T) {
 	executor := newExecutor()
 	executor.Instructions = append(executor.Instructions, MarkLabel{})
-	executor.LabelMap = map[string]int{
-		"F": 0,
-	}
 	executor.programCounter = 1
 
-	callSubroutine := CallSubroutine{Label: "F"}
+	callSubroutine := CallSubroutine{Label: "F", Target: 0}
 	callSubroutine.Execute(executor)
 
 	assert.Equal(t, 0, executor.programCounter)
@@ -266,12 +537,9 @@ func TestEndSubroutine(t *testing.T) {
 func TestJumpLabel(t *testing.T) {
 	executor := newExecutor()
 	executor.Instructions = append(executor.Instructions, MarkLabel{})
-	executor.LabelMap = map[string]int{
-		"F": 0,
-	}
 	executor.programCounter = 1
 
-	jumpLabel := JumpLabel{Label: "F"}
+	jumpLabel := JumpLabel{Label: "F", Target: 0}
 	jumpLabel.Execute(executor)
 
 	assert.Equal(t, 0, executor.programCounter)
@@ -280,19 +548,16 @@ func TestJumpLabel(t *testing.T) {
 func TestJumplabelWhenZero(t *testing.T) {
 	executor := newExecutor()
 	executor.Instructions = append(executor.Instructions, MarkLabel{})
-	executor.LabelMap = map[string]int{
-		"F": 0,
-	}
 	executor.programCounter = 1
-	executor.stack = []int{0}
+	executor.stack = numbers(0)
 
-	jumpLabelWhenZero := JumpLabelWhenZero{Label: "F"}
+	jumpLabelWhenZero := JumpLabelWhenZero{Label: "F", Target: 0}
 	jumpLabelWhenZero.Execute(executor)
 
 	assert.Equal(t, 0, executor.programCounter)
 
 	executor.programCounter = 1
-	executor.stack = []int{1}
+	executor.stack = numbers(1)
 	jumpLabelWhenZero.Execute(executor)
 
 	assert.Equal(t, 1, executor.programCounter)
@@ -301,25 +566,22 @@ func TestJumplabelWhenZero(t *testing.T) {
 func TestJumplabelWhenNegative(t *testing.T) {
 	executor := newExecutor()
 	executor.Instructions = append(executor.Instructions, MarkLabel{})
-	executor.LabelMap = map[string]int{
-		"F": 0,
-	}
 	executor.programCounter = 1
-	executor.stack = []int{-1}
+	executor.stack = numbers(-1)
 
-	jumpLabelWhenNegative := JumpLabelWhenNegative{Label: "F"}
+	jumpLabelWhenNegative := JumpLabelWhenNegative{Label: "F", Target: 0}
 	jumpLabelWhenNegative.Execute(executor)
 
 	assert.Equal(t, 0, executor.programCounter)
 
 	executor.programCounter = 1
-	executor.stack = []int{0}
+	executor.stack = numbers(0)
 	jumpLabelWhenNegative.Execute(executor)
 
 	assert.Equal(t, 1, executor.programCounter)
 
 	executor.programCounter = 1
-	executor.stack = []int{1}
+	executor.stack = numbers(1)
 	jumpLabelWhenNegative.Execute(executor)
 
 	assert.Equal(t, 1, executor.programCounter)
@@ -346,31 +608,64 @@ func TestRuntimeError(t *testing.T) {
 	})
 
 	t.Run("when copy to out of range", func(t *testing.T) {
-		executor.stack = []int{1}
+		executor.stack = numbers(1)
 		copy := Copy{Value: 1}
 		err := copy.Execute(executor)
 		assert.NotNil(t, err)
 	})
 
 	t.Run("when slide to out of range", func(t *testing.T) {
-		executor.stack = []int{1, 2, 3, 4}
+		executor.stack = numbers(1, 2, 3, 4)
 		slide := Slide{Value: 4}
 		err := slide.Execute(executor)
 		assert.NotNil(t, err)
 	})
 
 	t.Run("when invalid heap access", func(t *testing.T) {
-		executor.stack = []int{1}
-		executor.heap = map[int]int{}
+		executor.stack = numbers(1)
+		executor.heap = heapOf(map[int]int{})
 
 		retrieve := Retrieve{}
 		err := retrieve.Execute(executor)
 		assert.NotNil(t, err)
 	})
+}
 
-	t.Run("when label is not found", func(t *testing.T) {
-		jumpLabel := JumpLabel{Label: "F"}
-		err := jumpLabel.Execute(executor)
-		assert.NotNil(t, err)
-	})
+func TestRuntimeErrorKind(t *testing.T) {
+	token := lexer.Token{Type: lexer.Division, Literal: "LFLF", Line: 2, Column: 4}
+	division := Division{Source: NewSource(token, lexer.Span{})}
+
+	executor := newExecutor()
+	executor.Filename = "a.fflt"
+	executor.Instructions = []Instruction{Push{Value: NewNumber(1)}, Push{Value: NewNumber(0)}, division}
+	executor.programCounter = 2
+	executor.stack = numbers(1, 0)
+
+	err := division.Execute(executor)
+
+	var runtimeErr *RuntimeError
+	assert.True(t, errors.As(err, &runtimeErr))
+	assert.Equal(t, DivideByZero, runtimeErr.Kind)
+	assert.Equal(t, "a.fflt", runtimeErr.Filename)
+	assert.Equal(t, 2, runtimeErr.Line)
+	assert.Equal(t, 4, runtimeErr.Column)
+	assert.Equal(t, 2, runtimeErr.ProgramCounter)
+	assert.Equal(t, division, runtimeErr.Instruction)
+	assert.Equal(t, "Runtime error: integer divide by zero at a.fflt:2:4", err.Error())
+
+	executor.stack = numbers()
+	err = division.Execute(executor)
+
+	assert.True(t, errors.As(err, &runtimeErr))
+	assert.Equal(t, StackUnderflow, runtimeErr.Kind)
+}
+
+func TestSource(t *testing.T) {
+	token := lexer.Token{Type: lexer.Discard, Literal: "TFT", Line: 3, Column: 5}
+	span := lexer.Span{Start: lexer.Position{Offset: 0, Line: 1, Column: 1}, End: lexer.Position{Offset: 5, Line: 1, Column: 6}}
+
+	assert.Equal(t, token, Discard{Source: NewSource(token, span)}.Token())
+	assert.Equal(t, span, JumpLabel{Source: NewSource(token, span), Label: "F"}.Span())
+	assert.Equal(t, lexer.Token{}, EndProgram{}.Token())
+	assert.Equal(t, lexer.Span{}, Push{Value: NewNumber(1)}.Span())
 }
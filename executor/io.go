@@ -0,0 +1,231 @@
+package executor
+
+import (
+	"bufio"
+	"io"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/simomu-github/fflt_lang/lexer"
+)
+
+type EOFPolicy string
+
+const (
+	EOFError       = EOFPolicy("error")
+	EOFNegativeOne = EOFPolicy("-1")
+	EOFZero        = EOFPolicy("0")
+	EOFUnchanged   = EOFPolicy("unchanged")
+)
+
+func ParseEOFPolicy(str string) (EOFPolicy, bool) {
+	switch policy := EOFPolicy(str); policy {
+	case EOFError, EOFNegativeOne, EOFZero, EOFUnchanged:
+		return policy, true
+	default:
+		return "", false
+	}
+}
+
+func (executor *Executor) reader() *bufio.Reader {
+	if executor.bufferedInput == nil {
+		executor.bufferedInput = bufio.NewReader(executor.Input)
+	}
+	return executor.bufferedInput
+}
+
+// inputText is pending input. Queued text is given by the debugger's input
+// command rather than read from Input.
+type inputText struct {
+	text   string
+	queued bool
+}
+
+// queueInput makes the program read text after the input put back or queued
+// so far and before the rest of Input.
+func (executor *Executor) queueInput(text string) {
+	if text != "" {
+		executor.pendingInput = append(executor.pendingInput, inputText{text: text, queued: true})
+	}
+}
+
+// unreadInput puts inputs, which the program has read, back before the rest
+// of the input.
+func (executor *Executor) unreadInput(inputs []inputText) {
+	if len(inputs) == 0 {
+		return
+	}
+
+	executor.pendingInput = append(slices.Clone(inputs), executor.pendingInput...)
+	for _, input := range inputs {
+		if !input.queued {
+			executor.inputOffset -= int64(len(input.text))
+		}
+	}
+}
+
+// dropQueuedInput drops the queued pending input, keeping the input put back
+// and the input buffered from Input.
+func (executor *Executor) dropQueuedInput() {
+	pending := []inputText{}
+	for _, input := range executor.pendingInput {
+		if !input.queued {
+			pending = append(pending, input)
+		}
+	}
+	executor.pendingInput = pending
+}
+
+// unqueuedInputLength returns the number of bytes of the pending input which
+// dropQueuedInput keeps.
+func (executor *Executor) unqueuedInputLength() int {
+	length := 0
+	for _, input := range executor.pendingInput {
+		if !input.queued {
+			length += len(input.text)
+		}
+	}
+	return length
+}
+
+// skipPendingInput drops the first n bytes of the pending input.
+func (executor *Executor) skipPendingInput(n int) {
+	for n > 0 && len(executor.pendingInput) > 0 {
+		size := min(n, len(executor.pendingInput[0].text))
+		executor.consumePendingInput(size)
+		n -= size
+	}
+}
+
+// consumePendingInput drops the first n bytes of the first pending text.
+func (executor *Executor) consumePendingInput(n int) {
+	executor.pendingInput[0].text = executor.pendingInput[0].text[n:]
+	if executor.pendingInput[0].text == "" {
+		executor.pendingInput = executor.pendingInput[1:]
+	}
+}
+
+// readPendingInput reads the first n bytes of the first pending text.
+func (executor *Executor) readPendingInput(n int) {
+	input := executor.pendingInput[0]
+	input.text = input.text[:n]
+	executor.consumePendingInput(n)
+
+	if !input.queued {
+		executor.inputOffset += int64(n)
+	}
+	if executor.onInput != nil {
+		executor.onInput(input)
+	}
+}
+
+// readPendingLine reads the pending input up to and including the first LF,
+// or all of it when there is no LF.
+func (executor *Executor) readPendingLine() string {
+	line := ""
+	for len(executor.pendingInput) > 0 && !strings.HasSuffix(line, "\n") {
+		text := executor.pendingInput[0].text
+		n := strings.IndexByte(text, '\n') + 1
+		if n == 0 {
+			n = len(text)
+		}
+		line += text[:n]
+		executor.readPendingInput(n)
+	}
+	return line
+}
+
+func (executor *Executor) writer() *bufio.Writer {
+	if executor.bufferedOutput == nil {
+		executor.bufferedOutput = bufio.NewWriter(executor.Output)
+	}
+	return executor.bufferedOutput
+}
+
+// Flush writes any buffered output to Output.
+func (executor *Executor) Flush() error {
+	if executor.bufferedOutput == nil {
+		return nil
+	}
+	return executor.bufferedOutput.Flush()
+}
+
+func (executor *Executor) readRune() (rune, error) {
+	if err := executor.flushBeforeRead(); err != nil {
+		return 0, err
+	}
+
+	if len(executor.pendingInput) > 0 {
+		r, size := utf8.DecodeRuneInString(executor.pendingInput[0].text)
+		executor.readPendingInput(size)
+		return r, nil
+	}
+
+	reader := executor.reader()
+	r, size, err := reader.ReadRune()
+	executor.inputOffset += int64(size)
+	if err == nil && executor.onInput != nil {
+		// read the bytes again since an invalid byte is read as RuneError
+		reader.UnreadRune()
+		text := make([]byte, size)
+		io.ReadFull(reader, text)
+		executor.onInput(inputText{text: string(text)})
+	}
+	return r, err
+}
+
+func (executor *Executor) readLine() (string, error) {
+	if err := executor.flushBeforeRead(); err != nil {
+		return "", err
+	}
+
+	line := executor.readPendingLine()
+	var err error
+	if !strings.HasSuffix(line, "\n") {
+		var rest string
+		rest, err = executor.reader().ReadString('\n')
+		line += rest
+		executor.inputOffset += int64(len(rest))
+		if len(rest) > 0 && executor.onInput != nil {
+			executor.onInput(inputText{text: rest})
+		}
+	}
+	if err == io.EOF && len(line) > 0 {
+		err = nil
+	}
+	return strings.TrimRight(line, "\r\n"), err
+}
+
+// flushBeforeRead makes a prompt written by the program visible before
+// blocking on input.
+func (executor *Executor) flushBeforeRead() error {
+	if len(executor.pendingInput) > 0 || executor.bufferedInput != nil && executor.bufferedInput.Buffered() > 0 {
+		return nil
+	}
+	return executor.Flush()
+}
+
+func (executor *Executor) write(str string) error {
+	n, err := executor.writer().WriteString(str)
+	executor.outputOffset += int64(n)
+	if executor.RecordOutput {
+		executor.output.WriteString(str[:n])
+	}
+	return err
+}
+
+func (executor *Executor) eofIsError() bool {
+	return executor.EOF != EOFNegativeOne && executor.EOF != EOFZero && executor.EOF != EOFUnchanged
+}
+
+func (executor *Executor) storeEOF(token lexer.Token, address Number) error {
+	switch executor.EOF {
+	case EOFNegativeOne:
+		return executor.storeHeap(token, address, NewNumber(-1))
+	case EOFZero:
+		return executor.storeHeap(token, address, NewNumber(0))
+	default:
+		return nil
+	}
+}
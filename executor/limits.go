@@ -0,0 +1,50 @@
+package executor
+
+import (
+	"fmt"
+
+	"github.com/simomu-github/fflt_lang/lexer"
+)
+
+// Limits bounds the resources a program may use. Zero means unlimited.
+type Limits struct {
+	MaxSteps     int
+	MaxStack     int
+	MaxCallDepth int
+	MaxHeap      int
+}
+
+func (executor *Executor) checkStepLimit() error {
+	max := executor.Limits.MaxSteps
+	if max > 0 && executor.steps >= max {
+		return runtimeError(executor, StepLimitExceeded, fmt.Sprintf("executed instructions exceeded the limit (%d)", max))
+	}
+	return nil
+}
+
+func (executor *Executor) checkStackLimit(token lexer.Token) error {
+	max := executor.Limits.MaxStack
+	if max > 0 && len(executor.stack) >= max {
+		return runtimeErrorWithToken(executor, token, StackLimitExceeded, fmt.Sprintf("stack size exceeded the limit (%d)", max))
+	}
+	return nil
+}
+
+func (executor *Executor) checkCallDepthLimit(token lexer.Token) error {
+	max := executor.Limits.MaxCallDepth
+	if max > 0 && len(executor.callStack) >= max {
+		return runtimeErrorWithToken(executor, token, CallDepthLimitExceeded, fmt.Sprintf("call depth exceeded the limit (%d)", max))
+	}
+	return nil
+}
+
+func (executor *Executor) checkHeapLimit(token lexer.Token, address Number) error {
+	max := executor.Limits.MaxHeap
+	if max <= 0 || executor.heap.len() < max {
+		return nil
+	}
+	if _, ok := executor.heap.get(address); ok {
+		return nil
+	}
+	return runtimeErrorWithToken(executor, token, HeapLimitExceeded, fmt.Sprintf("heap cells exceeded the limit (%d)", max))
+}
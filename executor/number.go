@@ -0,0 +1,121 @@
+package executor
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+// Number is a value on the stack or in the heap. Values which do not fit in
+// an int are kept in big as hexadecimal text so that Number stays comparable
+// and can be used as a heap address.
+type Number struct {
+	small int
+	big   string
+}
+
+var wrapMask = new(big.Int).SetUint64(math.MaxUint)
+
+func NewNumber(n int) Number {
+	return Number{small: n}
+}
+
+func NewBigNumber(n *big.Int) Number {
+	if n.IsInt64() && n.Int64() >= math.MinInt && n.Int64() <= math.MaxInt {
+		return Number{small: int(n.Int64())}
+	}
+	return Number{big: n.Text(16)}
+}
+
+func ParseNumber(text string) (Number, bool) {
+	text = strings.TrimSpace(text)
+	if n, err := strconv.Atoi(text); err == nil {
+		return NewNumber(n), true
+	}
+
+	n, ok := new(big.Int).SetString(text, 10)
+	if !ok {
+		return Number{}, false
+	}
+	return NewBigNumber(n), true
+}
+
+func (n Number) IsInt() bool {
+	return n.big == ""
+}
+
+// Int returns n as an int, wrapping around values which do not fit.
+func (n Number) Int() int {
+	if n.IsInt() {
+		return n.small
+	}
+
+	wrapped := new(big.Int).And(n.Big(), wrapMask)
+	if bits.UintSize == 32 {
+		return int(int32(uint32(wrapped.Uint64())))
+	}
+	return int(wrapped.Uint64())
+}
+
+func (n Number) Big() *big.Int {
+	if n.IsInt() {
+		return big.NewInt(int64(n.small))
+	}
+
+	value, _ := new(big.Int).SetString(n.big, 16)
+	return value
+}
+
+func (n Number) Sign() int {
+	if n.IsInt() {
+		switch {
+		case n.small < 0:
+			return -1
+		case n.small > 0:
+			return 1
+		default:
+			return 0
+		}
+	}
+	if strings.HasPrefix(n.big, "-") {
+		return -1
+	}
+	return 1
+}
+
+func (n Number) Cmp(m Number) int {
+	if n.IsInt() && m.IsInt() {
+		switch {
+		case n.small < m.small:
+			return -1
+		case n.small > m.small:
+			return 1
+		default:
+			return 0
+		}
+	}
+	return n.Big().Cmp(m.Big())
+}
+
+func (n Number) String() string {
+	if n.IsInt() {
+		return strconv.Itoa(n.small)
+	}
+	return n.Big().String()
+}
+
+func (n Number) MarshalJSON() ([]byte, error) {
+	return []byte(n.String()), nil
+}
+
+func (n *Number) UnmarshalJSON(data []byte) error {
+	value, ok := ParseNumber(string(data))
+	if !ok {
+		return fmt.Errorf("invalid number: %s", string(data))
+	}
+	*n = value
+	return nil
+}
@@ -0,0 +1,38 @@
+package executor
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewBigNumber(t *testing.T) {
+	assert.Equal(t, NewNumber(-5), NewBigNumber(big.NewInt(-5)))
+
+	value, _ := new(big.Int).SetString("-340282366920938463463374607431768211456", 10)
+	n := NewBigNumber(value)
+
+	assert.False(t, n.IsInt())
+	assert.Equal(t, -1, n.Sign())
+	assert.Equal(t, "-340282366920938463463374607431768211456", n.String())
+	assert.Equal(t, 0, n.Cmp(NewBigNumber(value)))
+	assert.Equal(t, -1, n.Cmp(NewNumber(0)))
+}
+
+func TestNumberInt(t *testing.T) {
+	value, _ := ParseNumber("18446744073709551615")
+	assert.Equal(t, -1, value.Int())
+
+	value, _ = ParseNumber("-18446744073709551617")
+	assert.Equal(t, -1, value.Int())
+}
+
+func TestParseNumber(t *testing.T) {
+	value, ok := ParseNumber(" 42 ")
+	assert.True(t, ok)
+	assert.Equal(t, NewNumber(42), value)
+
+	_, ok = ParseNumber("4a")
+	assert.False(t, ok)
+}
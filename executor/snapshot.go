@@ -0,0 +1,139 @@
+package executor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const SnapshotVersion = 1
+
+// Snapshot is the whole state of the VM. Output is only filled when the
+// executor records output.
+type Snapshot struct {
+	Version        int            `json:"version"`
+	Filename       string         `json:"filename"`
+	Instructions   int            `json:"instructions"`
+	Arithmetic     ArithmeticMode `json:"arithmetic"`
+	ProgramCounter int            `json:"programCounter"`
+	Stack          []Number       `json:"stack"`
+	Heap           []HeapCell     `json:"heap"`
+	CallStack      []int          `json:"callStack"`
+	Steps          int            `json:"steps"`
+	InputOffset    int64          `json:"inputOffset"`
+	OutputOffset   int64          `json:"outputOffset"`
+	Output         string         `json:"output,omitempty"`
+}
+
+type HeapCell struct {
+	Address Number `json:"address"`
+	Value   Number `json:"value"`
+}
+
+func (executor *Executor) Snapshot() *Snapshot {
+	return &Snapshot{
+		Version:        SnapshotVersion,
+		Filename:       executor.Filename,
+		Instructions:   len(executor.Instructions),
+		Arithmetic:     executor.mode(),
+		ProgramCounter: executor.programCounter,
+		Stack:          append([]Number{}, executor.stack...),
+		Heap:           executor.heap.cells(),
+		CallStack:      append([]int{}, executor.callStack...),
+		Steps:          executor.steps,
+		InputOffset:    executor.inputOffset,
+		OutputOffset:   executor.outputOffset,
+		Output:         executor.output.String(),
+	}
+}
+
+// Restore replaces the VM state with snapshot. Input must be the same stream
+// the snapshot was taken from, positioned at its beginning; the consumed part
+// is skipped. Input queued by the debugger is not part of the snapshot.
+func (executor *Executor) Restore(snapshot *Snapshot) error {
+	if snapshot.Version != SnapshotVersion {
+		return fmt.Errorf("unsupported snapshot version: %d", snapshot.Version)
+	}
+
+	if snapshot.Instructions != len(executor.Instructions) {
+		return fmt.Errorf("snapshot is taken from a program with %d instructions, but the program has %d", snapshot.Instructions, len(executor.Instructions))
+	}
+
+	if snapshot.ProgramCounter < 0 || snapshot.ProgramCounter > len(executor.Instructions) {
+		return fmt.Errorf("program counter %d is out of the program", snapshot.ProgramCounter)
+	}
+
+	for _, counter := range snapshot.CallStack {
+		if counter < 0 || counter >= len(executor.Instructions) {
+			return fmt.Errorf("call stack entry %d is out of the program", counter)
+		}
+	}
+
+	if snapshot.Arithmetic != executor.mode() {
+		return fmt.Errorf("snapshot is taken in %s arithmetic, but the program runs in %s arithmetic", snapshot.Arithmetic, executor.mode())
+	}
+
+	if snapshot.InputOffset > 0 {
+		if executor.Input == nil {
+			return fmt.Errorf("snapshot has read %d bytes of input, but there is no input to skip them in", snapshot.InputOffset)
+		}
+		if _, err := executor.reader().Discard(int(snapshot.InputOffset)); err != nil {
+			return fmt.Errorf("can not skip consumed input: %w", err)
+		}
+	}
+
+	heap := newHeap()
+	for _, cell := range snapshot.Heap {
+		heap.set(cell.Address, cell.Value)
+	}
+
+	executor.programCounter = snapshot.ProgramCounter
+	executor.stack = append([]Number{}, snapshot.Stack...)
+	executor.heap = heap
+	executor.callStack = append([]int{}, snapshot.CallStack...)
+	executor.steps = snapshot.Steps
+	executor.inputOffset = snapshot.InputOffset
+	executor.outputOffset = snapshot.OutputOffset
+	executor.output.Reset()
+	executor.output.WriteString(snapshot.Output)
+
+	return nil
+}
+
+// Resumable reports whether err stopped the program before the instruction
+// at the program counter changed any state, so that a snapshot taken after
+// it resumes from a consistent state. Other errors may stop an instruction
+// part way, e.g. after it has popped its operands or read input.
+func Resumable(err error) bool {
+	var runtimeErr *RuntimeError
+	if !errors.As(err, &runtimeErr) {
+		return false
+	}
+
+	switch runtimeErr.Kind {
+	case Canceled, StepLimitExceeded, StackLimitExceeded, CallDepthLimitExceeded:
+		return true
+	default:
+		return false
+	}
+}
+
+func (snapshot *Snapshot) Encode(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(snapshot)
+}
+
+func DecodeSnapshot(r io.Reader) (*Snapshot, error) {
+	snapshot := &Snapshot{}
+	if err := json.NewDecoder(r).Decode(snapshot); err != nil {
+		return nil, err
+	}
+
+	if snapshot.Version != SnapshotVersion {
+		return nil, fmt.Errorf("unsupported snapshot version: %d", snapshot.Version)
+	}
+
+	return snapshot, nil
+}
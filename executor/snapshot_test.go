@@ -0,0 +1,120 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshotAndRestore(t *testing.T) {
+	instructions := []Instruction{
+		Push{Value: NewNumber(0)},
+		Getc{},
+		Push{Value: NewNumber(0)},
+		Retrieve{},
+		Putc{},
+		Push{Value: NewNumber(1)},
+		Getc{},
+		Push{Value: NewNumber(1)},
+		Retrieve{},
+		Putc{},
+	}
+
+	output := &bytes.Buffer{}
+	executor := &Executor{
+		Instructions: instructions,
+		Input:        strings.NewReader("ab"),
+		Output:       output,
+		Arithmetic:   ArithmeticBig,
+		Limits:       Limits{MaxSteps: 5},
+		RecordOutput: true,
+	}
+
+	err := executor.Run()
+	assert.NotNil(t, err)
+	assert.Equal(t, "a", output.String())
+
+	big, _ := ParseNumber("123456789012345678901234567890")
+	executor.stack = append(executor.stack, big)
+
+	encoded := &bytes.Buffer{}
+	assert.Nil(t, executor.Snapshot().Encode(encoded))
+
+	snapshot, err := DecodeSnapshot(encoded)
+	assert.Nil(t, err)
+	assert.Equal(t, 5, snapshot.ProgramCounter)
+	assert.Equal(t, ArithmeticBig, snapshot.Arithmetic)
+	assert.Equal(t, []Number{big}, snapshot.Stack)
+	assert.Equal(t, []HeapCell{{Address: NewNumber(0), Value: NewNumber(97)}}, snapshot.Heap)
+	assert.Equal(t, int64(1), snapshot.InputOffset)
+	assert.Equal(t, int64(1), snapshot.OutputOffset)
+	assert.Equal(t, "a", snapshot.Output)
+
+	output = &bytes.Buffer{}
+	restored := &Executor{
+		Instructions: instructions,
+		Input:        strings.NewReader("ab"),
+		Output:       output,
+		Arithmetic:   ArithmeticBig,
+	}
+
+	assert.Nil(t, restored.Restore(snapshot))
+	assert.Nil(t, restored.Resume(context.Background()))
+	assert.Equal(t, "b", output.String())
+	assert.Equal(t, []Number{big}, restored.stack)
+}
+
+func TestRestoreInvalidSnapshot(t *testing.T) {
+	executor := &Executor{
+		Instructions: []Instruction{EndProgram{}},
+		Input:        strings.NewReader(""),
+		Output:       &bytes.Buffer{},
+	}
+
+	t.Run("when version is different", func(t *testing.T) {
+		err := executor.Restore(&Snapshot{Version: SnapshotVersion + 1, Instructions: 1})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("when program is different", func(t *testing.T) {
+		err := executor.Restore(&Snapshot{Version: SnapshotVersion, Instructions: 2})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("when program counter is out of the program", func(t *testing.T) {
+		err := executor.Restore(&Snapshot{Version: SnapshotVersion, Instructions: 1, ProgramCounter: 2})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("when input is consumed but there is no input", func(t *testing.T) {
+		noInput := &Executor{Instructions: []Instruction{EndProgram{}}, Output: &bytes.Buffer{}}
+		err := noInput.Restore(&Snapshot{Version: SnapshotVersion, Instructions: 1, Arithmetic: ArithmeticWrap, InputOffset: 2})
+		assert.EqualError(t, err, "snapshot has read 2 bytes of input, but there is no input to skip them in")
+	})
+
+	t.Run("when arithmetic is different", func(t *testing.T) {
+		err := executor.Restore(&Snapshot{Version: SnapshotVersion, Instructions: 1, Arithmetic: ArithmeticBig})
+		assert.EqualError(t, err, "snapshot is taken in big arithmetic, but the program runs in wrap arithmetic")
+	})
+}
+
+func TestResumable(t *testing.T) {
+	executor := &Executor{
+		Instructions: []Instruction{Push{Value: NewNumber(1)}, Addition{}},
+		Input:        strings.NewReader(""),
+		Output:       &bytes.Buffer{},
+	}
+
+	executor.Limits.MaxSteps = 1
+	assert.True(t, Resumable(executor.Run()))
+
+	// ADD pops the right operand before it finds the stack empty
+	executor.Limits.MaxSteps = 0
+	assert.False(t, Resumable(executor.Run()))
+
+	assert.False(t, Resumable(errors.New("error")))
+}
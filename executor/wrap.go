@@ -0,0 +1,345 @@
+package executor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// wrapCode is the operation of an instruction compiled for runWrap.
+type wrapCode byte
+
+const (
+	// wrapOther is an instruction runWrap leaves to Instruction.Execute.
+	wrapOther wrapCode = iota
+	wrapPush
+	wrapSwap
+	wrapDuplicate
+	wrapDiscard
+	wrapCopy
+	wrapSlide
+	wrapAdd
+	wrapSubtract
+	wrapMultiply
+	wrapDivide
+	wrapModulo
+	wrapGetc
+	wrapGetn
+	wrapPutc
+	wrapPutn
+	wrapStore
+	wrapRetrieve
+	wrapMark
+	wrapCall
+	wrapEndSubroutine
+	wrapJump
+	wrapJumpWhenZero
+	wrapJumpWhenNegative
+	wrapEnd
+)
+
+type wrapOp struct {
+	code wrapCode
+	arg  int
+}
+
+func compileWrap(instructions []Instruction) []wrapOp {
+	ops := make([]wrapOp, len(instructions))
+	for i, instruction := range instructions {
+		switch instruction := instruction.(type) {
+		case Push:
+			ops[i] = wrapOp{wrapPush, instruction.Value.Int()}
+		case Swap:
+			ops[i] = wrapOp{code: wrapSwap}
+		case Duplicate:
+			ops[i] = wrapOp{code: wrapDuplicate}
+		case Discard:
+			ops[i] = wrapOp{code: wrapDiscard}
+		case Copy:
+			ops[i] = wrapOp{wrapCopy, instruction.Value}
+		case Slide:
+			ops[i] = wrapOp{wrapSlide, instruction.Value}
+		case Addition:
+			ops[i] = wrapOp{code: wrapAdd}
+		case Subtraction:
+			ops[i] = wrapOp{code: wrapSubtract}
+		case Multiplication:
+			ops[i] = wrapOp{code: wrapMultiply}
+		case Division:
+			ops[i] = wrapOp{code: wrapDivide}
+		case Modulo:
+			ops[i] = wrapOp{code: wrapModulo}
+		case Getc:
+			ops[i] = wrapOp{code: wrapGetc}
+		case Getn:
+			ops[i] = wrapOp{code: wrapGetn}
+		case Putc:
+			ops[i] = wrapOp{code: wrapPutc}
+		case Putn:
+			ops[i] = wrapOp{code: wrapPutn}
+		case Store:
+			ops[i] = wrapOp{code: wrapStore}
+		case Retrieve:
+			ops[i] = wrapOp{code: wrapRetrieve}
+		case MarkLabel:
+			ops[i] = wrapOp{code: wrapMark}
+		case CallSubroutine:
+			ops[i] = wrapOp{wrapCall, instruction.Target}
+		case EndSubroutine:
+			ops[i] = wrapOp{code: wrapEndSubroutine}
+		case JumpLabel:
+			ops[i] = wrapOp{wrapJump, instruction.Target}
+		case JumpLabelWhenZero:
+			ops[i] = wrapOp{wrapJumpWhenZero, instruction.Target}
+		case JumpLabelWhenNegative:
+			ops[i] = wrapOp{wrapJumpWhenNegative, instruction.Target}
+		case EndProgram:
+			ops[i] = wrapOp{code: wrapEnd}
+		}
+	}
+	return ops
+}
+
+// canRunWrap reports whether runWrap can take over the state: in wrap mode
+// with no heap watcher, every value fits in an int and stays so.
+func (executor *Executor) canRunWrap() bool {
+	if executor.mode() != ArithmeticWrap || executor.OnHeapAccess != nil || len(executor.heap.big) > 0 {
+		return false
+	}
+	for _, value := range executor.stack {
+		if !value.IsInt() {
+			return false
+		}
+	}
+	for _, value := range executor.heap.small {
+		if !value.IsInt() {
+			return false
+		}
+	}
+	return true
+}
+
+// runWrap runs the instructions of a wrap mode program on plain ints so
+// that the default mode does not pay for trap and big mode. It stops at the
+// first instruction it can not run the same way as Execute, e.g. one which
+// fails, and leaves the program counter there for the caller to go on.
+func (executor *Executor) runWrap() error {
+	ops := compileWrap(executor.Instructions)
+	stack := make([]int, len(executor.stack), cap(executor.stack))
+	for i, value := range executor.stack {
+		stack[i] = value.small
+	}
+	heap := make(map[int]int, len(executor.heap.small))
+	for address, value := range executor.heap.small {
+		heap[address] = value.small
+	}
+	callStack := executor.callStack
+
+	steps := 0
+	pc := executor.programCounter
+	// done is set when the instruction runWrap stops at has read or
+	// written, and what is left of it is finished by Execute's error
+	// handling
+	done := false
+	var char rune
+	var text string
+	var errIO error
+
+loop:
+	for ; pc < len(ops); pc++ {
+		steps++
+		op := ops[pc]
+		top := len(stack) - 1
+		switch op.code {
+		case wrapPush:
+			stack = append(stack, op.arg)
+		case wrapSwap:
+			if top < 1 {
+				break loop
+			}
+			stack[top], stack[top-1] = stack[top-1], stack[top]
+		case wrapDuplicate:
+			if top < 0 {
+				break loop
+			}
+			stack = append(stack, stack[top])
+		case wrapDiscard:
+			if top >= 0 {
+				stack = stack[:top]
+			}
+		case wrapCopy:
+			if op.arg < 0 || op.arg > top {
+				break loop
+			}
+			stack = append(stack, stack[top-op.arg])
+		case wrapSlide:
+			if op.arg < 0 || op.arg > top {
+				break loop
+			}
+			stack[top-op.arg] = stack[top]
+			stack = stack[:top-op.arg+1]
+		case wrapAdd:
+			if top < 1 {
+				break loop
+			}
+			stack[top-1] += stack[top]
+			stack = stack[:top]
+		case wrapSubtract:
+			if top < 1 {
+				break loop
+			}
+			stack[top-1] -= stack[top]
+			stack = stack[:top]
+		case wrapMultiply:
+			if top < 1 {
+				break loop
+			}
+			stack[top-1] *= stack[top]
+			stack = stack[:top]
+		case wrapDivide:
+			if top < 1 || stack[top] == 0 {
+				break loop
+			}
+			stack[top-1] /= stack[top]
+			stack = stack[:top]
+		case wrapModulo:
+			if top < 1 || stack[top] == 0 {
+				break loop
+			}
+			stack[top-1] %= stack[top]
+			stack = stack[:top]
+		case wrapGetc:
+			if top < 0 {
+				break loop
+			}
+			char, errIO = executor.readRune()
+			if errIO != nil {
+				done = true
+				break loop
+			}
+			heap[stack[top]] = int(char)
+			stack = stack[:top]
+		case wrapGetn:
+			if top < 0 {
+				break loop
+			}
+			text, errIO = executor.readLine()
+			n, err := strconv.Atoi(strings.TrimSpace(text))
+			if errIO != nil || err != nil {
+				done = true
+				break loop
+			}
+			heap[stack[top]] = n
+			stack = stack[:top]
+		case wrapPutc:
+			if top < 0 {
+				break loop
+			}
+			n := stack[top]
+			stack = stack[:top]
+			if errIO = executor.write(fmt.Sprintf("%c", n)); errIO != nil {
+				done = true
+				break loop
+			}
+		case wrapPutn:
+			if top < 0 {
+				break loop
+			}
+			n := stack[top]
+			stack = stack[:top]
+			if errIO = executor.write(strconv.Itoa(n)); errIO != nil {
+				done = true
+				break loop
+			}
+		case wrapStore:
+			if top < 1 {
+				break loop
+			}
+			heap[stack[top-1]] = stack[top]
+			stack = stack[:top-1]
+		case wrapRetrieve:
+			if top < 0 {
+				break loop
+			}
+			value, ok := heap[stack[top]]
+			if !ok {
+				break loop
+			}
+			stack[top] = value
+		case wrapMark:
+		case wrapCall:
+			callStack = append(callStack, pc)
+			pc = op.arg
+		case wrapEndSubroutine:
+			if len(callStack) == 0 {
+				break loop
+			}
+			pc = callStack[len(callStack)-1]
+			callStack = callStack[:len(callStack)-1]
+		case wrapJump:
+			pc = op.arg
+		case wrapJumpWhenZero:
+			if top < 0 {
+				break loop
+			}
+			n := stack[top]
+			stack = stack[:top]
+			if n == 0 {
+				pc = op.arg
+			}
+		case wrapJumpWhenNegative:
+			if top < 0 {
+				break loop
+			}
+			n := stack[top]
+			stack = stack[:top]
+			if n < 0 {
+				pc = op.arg
+			}
+		case wrapEnd:
+			pc = len(ops)
+		default:
+			break loop
+		}
+	}
+
+	executor.stack = make([]Number, len(stack), cap(stack))
+	for i, value := range stack {
+		executor.stack[i] = NewNumber(value)
+	}
+	executor.heap.small = make(map[int]Number, len(heap))
+	for address, value := range heap {
+		executor.heap.small[address] = NewNumber(value)
+	}
+	executor.callStack = callStack
+	executor.programCounter = pc
+	executor.steps += steps
+	if pc >= len(ops) {
+		return nil
+	}
+
+	if !done {
+		// Execute runs the instruction again from the start
+		executor.steps--
+		return nil
+	}
+
+	switch instruction := executor.Instructions[pc].(type) {
+	case Getc:
+		return executor.finishWrap(instruction.store(executor, char, errIO))
+	case Getn:
+		return executor.finishWrap(instruction.store(executor, text, errIO))
+	default:
+		return runtimeErrorWithToken(executor, instruction.Token(), IOError, errIO.Error())
+	}
+}
+
+// finishWrap moves past an instruction runWrap stopped at once Execute's
+// error handling has finished it.
+func (executor *Executor) finishWrap(err error) error {
+	if err != nil {
+		return err
+	}
+	executor.programCounter++
+	return nil
+}
@@ -3,7 +3,6 @@ module github.com/simomu-github/fflt_lang
 go 1.23
 
 require (
-	github.com/deckarep/golang-set/v2 v2.6.0
 	github.com/olekukonko/tablewriter v0.0.5
 	github.com/peterh/liner v1.2.2
 	github.com/stretchr/testify v1.7.1
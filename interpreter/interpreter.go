@@ -1,21 +1,39 @@
 package interpreter
 
 import (
-	"bufio"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
 
+	"github.com/simomu-github/fflt_lang/assembler"
+	"github.com/simomu-github/fflt_lang/dap"
 	"github.com/simomu-github/fflt_lang/executor"
 	"github.com/simomu-github/fflt_lang/lexer"
 	"github.com/simomu-github/fflt_lang/parser"
 )
 
 var (
-	versionOpt = flag.Bool("v", false, "display version information")
-	dumpOpt    = flag.Bool("dump", false, "disassemble instructions")
-	debugOpt   = flag.Bool("debug", false, "run with debugger")
+	versionOpt      = flag.Bool("v", false, "display version information")
+	dumpOpt         = flag.Bool("dump", false, "disassemble instructions")
+	debugOpt        = flag.Bool("debug", false, "run with debugger")
+	debugScriptOpt  = flag.String("debug-script", "", "run with debugger reading commands from the file")
+	historySizeOpt  = flag.Int("history-size", executor.DefaultHistorySize, "number of instructions the debugger can step back")
+	checkOpt        = flag.Bool("check", false, "report diagnostics without running")
+	bignumOpt       = flag.Bool("bignum", false, "use arbitrary-precision integers")
+	overflowOpt     = flag.String("overflow", "wrap", "integer overflow behavior: wrap or trap")
+	maxStepsOpt     = flag.Int("max-steps", 0, "maximum number of executed instructions (0 means unlimited)")
+	maxStackOpt     = flag.Int("max-stack", 0, "maximum stack size (0 means unlimited)")
+	maxCallDepthOpt = flag.Int("max-call-depth", 0, "maximum subroutine call depth (0 means unlimited)")
+	maxHeapOpt      = flag.Int("max-heap", 0, "maximum number of heap cells (0 means unlimited)")
+	timeoutOpt      = flag.Duration("timeout", 0, "stop the program after the duration (0 means no timeout)")
+	snapshotOpt     = flag.String("snapshot", "", "write the VM state to the file when the program is stopped by a limit, -timeout or Ctrl-C")
+	restoreOpt      = flag.String("restore", "", "resume the program from the VM state in the file")
+	eofOpt          = flag.String("eof", "error", "end of input behavior of GETC and GETN: error, -1, 0 or unchanged")
+	alphabetOpt     = flag.String("alphabet", "", "symbols for F, L and T, e.g. \"🍣,🍺,🍜\", or fflt or ws (default by the file extension)")
 )
 
 const version = "v0.0.3"
@@ -32,7 +50,7 @@ func New() *Interpreter {
 
 func (i *Interpreter) Run() int {
 	flag.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage of %s:\n  fflt_lang [FILE]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Usage of %s:\n  fflt_lang [FILE]\n  fflt_lang asm [FILE]\n  fflt_lang convert -from ws -to fflt [FILE]\n  fflt_lang dap\n", os.Args[0])
 		flag.PrintDefaults()
 	}
 
@@ -47,6 +65,22 @@ func (i *Interpreter) Run() int {
 		return 1
 	}
 
+	if flag.Arg(0) == "dap" {
+		return i.runDAP()
+	}
+
+	if flag.Arg(0) == "convert" {
+		return i.runConverter(flag.Args()[1:])
+	}
+
+	if flag.Arg(0) == "asm" {
+		if len(flag.Args()) < 2 {
+			flag.Usage()
+			return 1
+		}
+		return i.runAssembler(flag.Arg(1))
+	}
+
 	filename := flag.Arg(0)
 
 	bytes, errReadFile := os.ReadFile(filename)
@@ -55,15 +89,25 @@ func (i *Interpreter) Run() int {
 		return 1
 	}
 
-	tokens, lexErr := lexer.ScanAllTokens(string(bytes), filename)
-	if lexErr != nil {
-		fmt.Fprintln(i.stderr, lexErr.Error())
+	alphabet, err := fileAlphabet(filename, *alphabetOpt)
+	if err != nil {
+		fmt.Fprintln(i.stderr, err.Error())
 		return 1
 	}
 
+	tokens, lexErr := lexer.ScanAllTokensWithAlphabet(string(bytes), filename, alphabet)
+	if *checkOpt {
+		return i.check(tokens, lexErr, string(bytes), filename)
+	}
+
 	instructions, labelMap, parseErr := parser.ParseAll(tokens, filename)
-	if parseErr != nil {
-		fmt.Fprintln(i.stderr, parseErr.Error())
+	if lexErr != nil || parseErr != nil {
+		if lexErr != nil {
+			i.printError(lexErr, string(bytes))
+		}
+		if parseErr != nil {
+			i.printError(parseErr, string(bytes))
+		}
 		return 1
 	}
 
@@ -71,23 +115,64 @@ func (i *Interpreter) Run() int {
 		Filename:     filename,
 		Instructions: instructions,
 		LabelMap:     labelMap,
-		Input: func() string {
-			stdin := bufio.NewScanner(os.Stdin)
-			stdin.Scan()
-			return stdin.Text()
-		},
-		Output: func(str string) {
-			fmt.Printf(str)
-		},
+		Input:        os.Stdin,
+		Output:       os.Stdout,
+	}
+
+	switch {
+	case *bignumOpt && *overflowOpt == "trap":
+		fmt.Fprintln(i.stderr, "-bignum and -overflow=trap can not be used together")
+		return 1
+	case *bignumOpt:
+		exe.Arithmetic = executor.ArithmeticBig
+	case *overflowOpt == "trap":
+		exe.Arithmetic = executor.ArithmeticTrap
+	case *overflowOpt == "wrap":
+		exe.Arithmetic = executor.ArithmeticWrap
+	default:
+		fmt.Fprintf(i.stderr, "invalid -overflow value: %s\n", *overflowOpt)
+		return 1
+	}
+
+	eofPolicy, ok := executor.ParseEOFPolicy(*eofOpt)
+	if !ok {
+		fmt.Fprintf(i.stderr, "invalid -eof value: %s\n", *eofOpt)
+		return 1
+	}
+	exe.EOF = eofPolicy
+
+	exe.Limits = executor.Limits{
+		MaxSteps:     *maxStepsOpt,
+		MaxStack:     *maxStackOpt,
+		MaxCallDepth: *maxCallDepthOpt,
+		MaxHeap:      *maxHeapOpt,
 	}
 
 	if *dumpOpt {
-		exe.Disassenble()
+		if err := exe.Disassenble(); err != nil {
+			fmt.Fprintln(i.stderr, err.Error())
+			return 1
+		}
 		return 0
 	}
 
-	if *debugOpt {
+	if *debugOpt || *debugScriptOpt != "" {
 		debugger := executor.NewDebugger(&exe)
+		debugger.HistorySize = *historySizeOpt
+		debugger.Reload = func() ([]executor.Instruction, map[string]int, error) {
+			return parser.ParseFile(filename, alphabet)
+		}
+		if *debugScriptOpt != "" {
+			script, err := os.Open(*debugScriptOpt)
+			if err != nil {
+				fmt.Fprintf(i.stderr, "%s can not read\n", *debugScriptOpt)
+				return 1
+			}
+			defer script.Close()
+
+			debugger.Commands = executor.NewScriptCommands(script, os.Stdout)
+			debugger.Input = os.Stdin
+		}
 		if err := debugger.Run(); err != nil {
 			fmt.Fprintln(i.stderr, err.Error())
 			return 1
@@ -95,11 +180,227 @@ func (i *Interpreter) Run() int {
 		return 0
 	}
 
-	errRuntime := exe.Run()
+	ctx := context.Background()
+	if *timeoutOpt > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeoutOpt)
+		defer cancel()
+	}
+
+	if *snapshotOpt != "" {
+		exe.RecordOutput = true
+
+		var stop context.CancelFunc
+		ctx, stop = signal.NotifyContext(ctx, os.Interrupt)
+		defer stop()
+	}
+
+	var errRuntime error
+	if *restoreOpt != "" {
+		if err := i.restoreSnapshot(&exe, *restoreOpt); err != nil {
+			fmt.Fprintln(i.stderr, err.Error())
+			return 1
+		}
+		errRuntime = exe.Resume(ctx)
+	} else {
+		errRuntime = exe.RunContext(ctx)
+	}
+
 	if errRuntime != nil {
-		fmt.Fprintln(i.stderr, errRuntime.Error())
+		i.printError(errRuntime, string(bytes))
+		if *snapshotOpt != "" {
+			if !executor.Resumable(errRuntime) {
+				fmt.Fprintf(i.stderr, "VM state is not written since the instruction failed part way\n")
+			} else if err := i.writeSnapshot(&exe, *snapshotOpt); err != nil {
+				fmt.Fprintln(i.stderr, err.Error())
+			}
+		}
+		return 1
+	}
+
+	return 0
+}
+
+// check reports syntax errors and the diagnostics of every instruction which
+// could be parsed, so that one run reports all problems.
+func (i *Interpreter) check(tokens []lexer.Token, lexErr error, source string, filename string) int {
+	diagnostics, parseErr := parser.Check(tokens, filename)
+	if lexErr != nil {
+		i.printError(lexErr, source)
+	}
+	if parseErr != nil {
+		i.printError(parseErr, source)
+	}
+
+	for _, diagnostic := range diagnostics {
+		i.printDiagnostic(diagnostic, source)
+	}
+
+	if lexErr != nil || parseErr != nil || diagnostics.HasErrors() {
+		return 1
+	}
+	return 0
+}
+
+func (i *Interpreter) printDiagnostic(diagnostic parser.Diagnostic, source string) {
+	fmt.Fprintln(i.stderr, diagnostic.String())
+	if underline := diagnostic.Underline(source); underline != "" {
+		fmt.Fprintln(i.stderr, underline)
+	}
+}
+
+// printError prints each error in err, followed by its source underlined
+// when it is a syntax or runtime error which knows where it is.
+func (i *Interpreter) printError(err error, source string) {
+	errs := []error{err}
+	var list lexer.ErrorList
+	if errors.As(err, &list) {
+		errs = list
+	}
+
+	for _, err := range errs {
+		fmt.Fprintln(i.stderr, err.Error())
+		if underline := errorSpan(err).Underline(source); underline != "" {
+			fmt.Fprintln(i.stderr, underline)
+		}
+	}
+}
+
+func errorSpan(err error) lexer.Span {
+	var syntaxErr *lexer.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return syntaxErr.Span
+	}
+
+	var runtimeErr *executor.RuntimeError
+	if errors.As(err, &runtimeErr) && runtimeErr.Instruction != nil {
+		return runtimeErr.Instruction.Span()
+	}
+	return lexer.Span{}
+}
+
+func (i *Interpreter) runAssembler(filename string) int {
+	bytes, err := os.ReadFile(filename)
+	if err != nil {
+		fmt.Fprintf(i.stderr, "%s can not read\n", filename)
+		return 1
+	}
+
+	source, err := assembler.Assemble(string(bytes), filename)
+	if err != nil {
+		fmt.Fprintln(i.stderr, err.Error())
+		return 1
+	}
+
+	fmt.Print(source)
+	return 0
+}
+
+var alphabets = map[string]lexer.Alphabet{
+	"fflt": lexer.DefaultAlphabet,
+	"ws":   lexer.WhitespaceAlphabet,
+}
+
+// parseAlphabet returns the alphabet named fflt or ws, or the one defined by
+// definition.
+func parseAlphabet(definition string) (lexer.Alphabet, error) {
+	if alphabet, ok := alphabets[definition]; ok {
+		return alphabet, nil
+	}
+	return lexer.ParseAlphabet(definition)
+}
+
+func fileAlphabet(filename string, definition string) (lexer.Alphabet, error) {
+	if definition == "" {
+		return lexer.FileAlphabet(filename), nil
+	}
+	return parseAlphabet(definition)
+}
+
+func (i *Interpreter) runConverter(args []string) int {
+	flags := flag.NewFlagSet("convert", flag.ContinueOnError)
+	flags.SetOutput(i.stderr)
+	fromOpt := flags.String("from", "", "language of the source: fflt, ws or an alphabet (default by the file extension)")
+	toOpt := flags.String("to", "", "language to convert to: fflt, ws or an alphabet")
+	if err := flags.Parse(args); err != nil || flags.NArg() < 1 {
+		fmt.Fprintln(i.stderr, "Usage: fflt_lang convert -from ws -to fflt [FILE]")
+		return 1
+	}
+
+	filename := flags.Arg(0)
+	from, err := fileAlphabet(filename, *fromOpt)
+	if err != nil {
+		fmt.Fprintln(i.stderr, err.Error())
+		return 1
+	}
+	to, err := parseAlphabet(*toOpt)
+	if err != nil {
+		fmt.Fprintln(i.stderr, err.Error())
+		return 1
+	}
+
+	bytes, err := os.ReadFile(filename)
+	if err != nil {
+		fmt.Fprintf(i.stderr, "%s can not read\n", filename)
+		return 1
+	}
+
+	tokens, lexErr := lexer.ScanAllTokensWithAlphabet(string(bytes), filename, from)
+	_, _, parseErr := parser.ParseAll(tokens, filename)
+	if lexErr != nil || parseErr != nil {
+		if lexErr != nil {
+			i.printError(lexErr, string(bytes))
+		}
+		if parseErr != nil {
+			i.printError(parseErr, string(bytes))
+		}
+		return 1
+	}
+
+	converted, err := lexer.Convert(tokens, to)
+	if err != nil {
+		fmt.Fprintln(i.stderr, err.Error())
 		return 1
 	}
 
+	fmt.Print(converted)
 	return 0
 }
+
+func (i *Interpreter) runDAP() int {
+	if err := dap.NewServer(os.Stdin, os.Stdout).Serve(); err != nil {
+		fmt.Fprintln(i.stderr, err.Error())
+		return 1
+	}
+	return 0
+}
+
+func (i *Interpreter) restoreSnapshot(exe *executor.Executor, filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return fmt.Errorf("%s can not read", filename)
+	}
+	defer f.Close()
+
+	snapshot, err := executor.DecodeSnapshot(f)
+	if err != nil {
+		return fmt.Errorf("%s is not a valid snapshot: %w", filename, err)
+	}
+
+	return exe.Restore(snapshot)
+}
+
+func (i *Interpreter) writeSnapshot(exe *executor.Executor, filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("%s can not write", filename)
+	}
+	defer f.Close()
+
+	if err := exe.Snapshot().Encode(f); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(i.stderr, "VM state is written to %s\n", filename)
+	return nil
+}
@@ -0,0 +1,146 @@
+package lexer
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Alphabet is the symbols which are read as F, L and T. A symbol is one or
+// more runes, and the longest symbol matching the source is read. The first
+// symbol of each is used when writing source.
+type Alphabet struct {
+	F []string
+	L []string
+	T []string
+}
+
+var (
+	DefaultAlphabet    = Alphabet{F: []string{UpperF, LowerF}, L: []string{UpperL, LowerL}, T: []string{UpperT, LowerT}}
+	WhitespaceAlphabet = Alphabet{F: []string{" "}, L: []string{"\t"}, T: []string{LF}}
+)
+
+// FileAlphabet returns the alphabet of the file, which is Whitespace for
+// files with the .ws extension.
+func FileAlphabet(filename string) Alphabet {
+	if filepath.Ext(filename) == ".ws" {
+		return WhitespaceAlphabet
+	}
+	return DefaultAlphabet
+}
+
+// ParseAlphabet parses the symbols for F, L and T separated by commas, e.g.
+// "🍣,🍺,🍜". Alternative symbols are separated by "|", e.g. "F|f,L|l,T|t".
+func ParseAlphabet(definition string) (Alphabet, error) {
+	roles := strings.Split(definition, ",")
+	if len(roles) != 3 {
+		return Alphabet{}, fmt.Errorf("invalid alphabet %q: expected symbols for F, L and T", definition)
+	}
+
+	symbols := make([][]string, len(roles))
+	for i, role := range roles {
+		symbols[i] = strings.Split(role, "|")
+	}
+
+	alphabet := Alphabet{F: symbols[0], L: symbols[1], T: symbols[2]}
+	if err := alphabet.Validate(); err != nil {
+		return Alphabet{}, fmt.Errorf("invalid alphabet %q: %w", definition, err)
+	}
+	return alphabet, nil
+}
+
+// Validate reports whether F, L and T each have a symbol and no symbol is
+// empty or stands for more than one of them.
+func (alphabet Alphabet) Validate() error {
+	seen := map[string]bool{}
+	for _, role := range []struct {
+		name    string
+		symbols []string
+	}{
+		{UpperF, alphabet.F},
+		{UpperL, alphabet.L},
+		{UpperT, alphabet.T},
+	} {
+		if len(role.symbols) == 0 {
+			return fmt.Errorf("no symbol for %s", role.name)
+		}
+		for _, symbol := range role.symbols {
+			if symbol == "" {
+				return errors.New("empty symbol")
+			}
+			if seen[symbol] {
+				return fmt.Errorf("%q is used more than once", symbol)
+			}
+			seen[symbol] = true
+		}
+	}
+	return nil
+}
+
+// match returns the longest symbol at the start of text and the F, L or T it
+// stands for.
+func (alphabet Alphabet) match(text string) (string, string, bool) {
+	longest := ""
+	for _, chars := range [][]string{alphabet.F, alphabet.L, alphabet.T} {
+		for _, c := range chars {
+			if len(c) > len(longest) && strings.HasPrefix(text, c) {
+				longest = c
+			}
+		}
+	}
+	if longest == "" {
+		return "", "", false
+	}
+
+	char, _ := alphabet.canonical(longest)
+	return longest, char, true
+}
+
+// canonical returns the F, L or T which char stands for. Lower case f, l and
+// t keep their case since labels are case sensitive.
+func (alphabet Alphabet) canonical(char string) (string, bool) {
+	for _, set := range []struct {
+		chars []string
+		upper string
+		lower string
+	}{
+		{alphabet.F, UpperF, LowerF},
+		{alphabet.L, UpperL, LowerL},
+		{alphabet.T, UpperT, LowerT},
+	} {
+		for _, c := range set.chars {
+			if c != char {
+				continue
+			}
+			if c == set.lower {
+				return set.lower, true
+			}
+			return set.upper, true
+		}
+	}
+	return "", false
+}
+
+// Encode writes the F, L and T of literal with the alphabet. Characters in
+// the alphabet themselves are kept. The alphabet must be valid.
+func (alphabet Alphabet) Encode(literal string) string {
+	var builder strings.Builder
+	for _, char := range literal {
+		c := string(char)
+		if canonical, ok := alphabet.canonical(c); ok && strings.EqualFold(canonical, c) {
+			builder.WriteString(c)
+			continue
+		}
+
+		switch strings.ToUpper(c) {
+		case UpperF:
+			builder.WriteString(alphabet.F[0])
+		case UpperL:
+			builder.WriteString(alphabet.L[0])
+		case UpperT:
+			builder.WriteString(alphabet.T[0])
+		}
+	}
+	return builder.String()
+}
@@ -0,0 +1,70 @@
+package lexer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAlphabet(t *testing.T) {
+	alphabet, err := ParseAlphabet("🍣,🍺|ビール,🍜")
+	assert.NoError(t, err)
+	assert.Equal(t, Alphabet{F: []string{"🍣"}, L: []string{"🍺", "ビール"}, T: []string{"🍜"}}, alphabet)
+
+	_, err = ParseAlphabet("a,b")
+	assert.EqualError(t, err, "invalid alphabet \"a,b\": expected symbols for F, L and T")
+
+	_, err = ParseAlphabet("a,|b,c")
+	assert.EqualError(t, err, "invalid alphabet \"a,|b,c\": empty symbol")
+
+	_, err = ParseAlphabet("a,b,a")
+	assert.EqualError(t, err, "invalid alphabet \"a,b,a\": \"a\" is used more than once")
+}
+
+func TestScanMultiRuneAlphabet(t *testing.T) {
+	alphabet, _ := ParseAlphabet("🍣,🍺|ビール,🍜")
+	source := "寿司🍣🍣 🍣ビール🍜" + "🍺🍜🍣🍺"
+
+	expectedTokens := []Token{
+		Token{Type: Push, Literal: "FF", Line: 1, Column: 4,
+			Start: Position{Offset: 6, Line: 1, Column: 3}, End: Position{Offset: 10, Line: 1, Column: 4}},
+		Token{Type: Number, Literal: "FLT", Line: 1, Column: 10,
+			Start: Position{Offset: 15, Line: 1, Column: 6}, End: Position{Offset: 28, Line: 1, Column: 10}},
+
+		Token{Type: Putn, Literal: "LTFL", Line: 1, Column: 14,
+			Start: Position{Offset: 32, Line: 1, Column: 11}, End: Position{Offset: 44, Line: 1, Column: 14}},
+	}
+
+	tokens, err := ScanAllTokensWithAlphabet(source, "", alphabet)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expectedTokens, tokens)
+}
+
+func TestScanLongestSymbol(t *testing.T) {
+	alphabet, _ := ParseAlphabet("a,aa,b")
+
+	tokens, err := ScanAllTokensWithAlphabet("a a aaab", "", alphabet)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []Token{
+		Token{Type: Push, Literal: "FF", Line: 1, Column: 3,
+			Start: Position{Offset: 0, Line: 1, Column: 1}, End: Position{Offset: 2, Line: 1, Column: 3}},
+		Token{Type: Number, Literal: "LFT", Line: 1, Column: 8,
+			Start: Position{Offset: 4, Line: 1, Column: 5}, End: Position{Offset: 7, Line: 1, Column: 8}},
+	}, tokens)
+}
+
+func TestScanInvalidAlphabet(t *testing.T) {
+	_, err := ScanAllTokensWithAlphabet("FFFLT", "", Alphabet{F: []string{"a"}, L: []string{"a"}, T: []string{"b"}})
+
+	assert.EqualError(t, err, "invalid alphabet: \"a\" is used more than once")
+}
+
+func TestEncode(t *testing.T) {
+	alphabet, _ := ParseAlphabet("l,f,t")
+
+	assert.Equal(t, "lft", alphabet.Encode("FLT"))
+	assert.Equal(t, "fFt", DefaultAlphabet.Encode("fFt"))
+	assert.Equal(t, " \t\n", WhitespaceAlphabet.Encode("fLt"))
+}
@@ -0,0 +1,65 @@
+package lexer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Convert writes tokens as source in alphabet. Each instruction starts on a
+// new line unless LF is a character of the alphabet.
+//
+// Labels are case sensitive, so labels which become the same in an alphabet
+// without lower case are rewritten with two characters for each character:
+// F as FF, f as FL, L as LF and l as LL.
+func Convert(tokens []Token, alphabet Alphabet) (string, error) {
+	if err := alphabet.Validate(); err != nil {
+		return "", fmt.Errorf("invalid alphabet: %w", err)
+	}
+
+	_, lfInAlphabet := alphabet.canonical(LF)
+	rewriteLabels := labelsCollide(tokens, alphabet)
+
+	var builder strings.Builder
+	for i, token := range tokens {
+		if i > 0 && !lfInAlphabet && token.Type != Number && token.Type != Label {
+			builder.WriteString(LF)
+		}
+
+		literal := token.Literal
+		if token.Type == Label && rewriteLabels {
+			literal = widenLabel(literal)
+		}
+		builder.WriteString(alphabet.Encode(literal))
+	}
+	if len(tokens) > 0 && !lfInAlphabet {
+		builder.WriteString(LF)
+	}
+
+	return builder.String(), nil
+}
+
+func labelsCollide(tokens []Token, alphabet Alphabet) bool {
+	encoded := map[string]string{}
+	for _, token := range tokens {
+		if token.Type != Label {
+			continue
+		}
+
+		label := alphabet.Encode(token.Literal)
+		if literal, ok := encoded[label]; ok && literal != token.Literal {
+			return true
+		}
+		encoded[label] = token.Literal
+	}
+	return false
+}
+
+func widenLabel(literal string) string {
+	body := literal[:len(literal)-1]
+	return strings.NewReplacer(
+		UpperF, UpperF+UpperF,
+		LowerF, UpperF+UpperL,
+		UpperL, UpperL+UpperF,
+		LowerL, UpperL+UpperL,
+	).Replace(body) + literal[len(literal)-1:]
+}
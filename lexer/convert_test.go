@@ -0,0 +1,39 @@
+package lexer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvert(t *testing.T) {
+	tokens, err := ScanAllTokens("FFFLT LTFL TFFfT TFTfT", "")
+	assert.NoError(t, err)
+
+	converted, err := Convert(tokens, DefaultAlphabet)
+	assert.NoError(t, err)
+	assert.Equal(t, "FFFLT\nLTFL\nTFFfT\nTFTfT\n", converted)
+
+	converted, err = Convert(tokens, WhitespaceAlphabet)
+	assert.NoError(t, err)
+	assert.Equal(t, "   \t\n\t\n \t\n   \n\n \n \n", converted)
+
+	_, err = Convert(tokens, Alphabet{F: []string{"a"}, L: []string{"b"}})
+	assert.EqualError(t, err, "invalid alphabet: no symbol for T")
+}
+
+func TestConvertCollidingLabels(t *testing.T) {
+	tokens, err := ScanAllTokens("TFFfT TFFFT TFTfT", "")
+	assert.NoError(t, err)
+
+	converted, err := Convert(tokens, WhitespaceAlphabet)
+	assert.NoError(t, err)
+	assert.Equal(t, "\n   \t\n"+"\n    \n"+"\n \n \t\n", converted)
+
+	tokens, err = ScanAllTokensWithAlphabet(converted, "", WhitespaceAlphabet)
+	assert.NoError(t, err)
+
+	converted, err = Convert(tokens, DefaultAlphabet)
+	assert.NoError(t, err)
+	assert.Equal(t, "TFFFLT\nTFFFFT\nTFTFLT\n", converted)
+}
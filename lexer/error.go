@@ -1,11 +1,50 @@
 package lexer
 
 import (
-	"errors"
 	"fmt"
+	"strings"
 )
 
+type SyntaxError struct {
+	Filename string
+	Line     int
+	Column   int
+	Message  string
+	// Span is the source the error is about, or zero when it is unknown.
+	Span Span
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("Syntex error: %s at %s:%d:%d", e.Message, e.Filename, e.Line, e.Column)
+}
+
+type ErrorList []error
+
+func (list ErrorList) Error() string {
+	messages := make([]string, len(list))
+	for i, err := range list {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+func (list ErrorList) Unwrap() []error {
+	return list
+}
+
+func (list ErrorList) Err() error {
+	if len(list) == 0 {
+		return nil
+	}
+	return list
+}
+
 func lexicalError(lexer *Lexer, message string) error {
-	errorMessage := fmt.Sprintf("Syntex error: %s at %s:%d:%d", message, lexer.filename, lexer.currentLine, lexer.currentColumn)
-	return errors.New(errorMessage)
+	return &SyntaxError{
+		Filename: lexer.filename,
+		Line:     lexer.currentLine,
+		Column:   lexer.currentColumn,
+		Message:  message,
+		Span:     Span{Start: lexer.tokenStart, End: lexer.tokenEnd},
+	}
 }
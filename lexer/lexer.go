@@ -1,38 +1,65 @@
 package lexer
 
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
 type Lexer struct {
 	filename      string
 	source        string
+	alphabet      Alphabet
 	currentIndex  int
+	currentWidth  int
 	newLine       bool
 	currentColumn int
 	currentLine   int
 	currentToken  string
+	tokenStart    Position
+	tokenEnd      Position
 	tokens        []Token
 }
 
 func ScanAllTokens(source string, filename string) ([]Token, error) {
+	return ScanAllTokensWithAlphabet(source, filename, DefaultAlphabet)
+}
+
+// ScanAllTokensWithAlphabet scans source written with alphabet. Token
+// literals are written with F, L and T.
+func ScanAllTokensWithAlphabet(source string, filename string, alphabet Alphabet) ([]Token, error) {
+	if err := alphabet.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid alphabet: %w", err)
+	}
+
 	lexer := &Lexer{
 		filename:      filename,
 		source:        source,
+		alphabet:      alphabet,
 		currentIndex:  -1,
+		currentWidth:  1,
 		currentLine:   1,
 		currentColumn: 0,
 		newLine:       false,
 	}
 
 	var allTokens []Token
+	var errs ErrorList
 
 	for lexer.currentIndex < len(lexer.source) {
+		start := *lexer
 		tokens, err := lexer.scanToken()
 		if err != nil {
-			return []Token{}, err
+			errs = append(errs, err)
+			*lexer = start
+			lexer.readNextChar()
+			tokens = lexer.resync()
 		}
 
 		allTokens = append(allTokens, tokens...)
 	}
 
-	return allTokens, nil
+	return allTokens, errs.Err()
 }
 
 func (lexer *Lexer) scanToken() ([]Token, error) {
@@ -58,22 +85,41 @@ func (lexer *Lexer) scanToken() ([]Token, error) {
 	return lexer.tokens, nil
 }
 
+// resync skips to the next symbol from which an instruction can be scanned
+// and returns its tokens, so that an invalid sequence is reported once rather
+// than as a cascade of errors. It is called one symbol after the start of the
+// failed instruction, so the symbols the failed scan read can start the next
+// instruction.
+func (lexer *Lexer) resync() []Token {
+	for lexer.currentIndex < len(lexer.source) {
+		start := *lexer
+		tokens, err := lexer.scanToken()
+		if err == nil {
+			return tokens
+		}
+
+		*lexer = start
+		lexer.readNextChar()
+	}
+	return nil
+}
+
 func (lexer *Lexer) scanStackManipulation() ([]Token, error) {
 	char := lexer.readNextChar()
 	switch char {
 	case UpperF, LowerF:
-		pushToken := Token{Type: Push, Literal: lexer.currentToken, Line: lexer.currentLine, Column: lexer.currentColumn}
+		pushToken := lexer.newToken(Push)
 		numberToken := lexer.scanValue(Number)
 		return []Token{pushToken, numberToken}, nil
 	case UpperL, LowerL:
 		char = lexer.readNextChar()
 		switch char {
 		case UpperF, LowerF:
-			copyToken := Token{Type: Copy, Literal: lexer.currentToken, Line: lexer.currentLine, Column: lexer.currentColumn}
+			copyToken := lexer.newToken(Copy)
 			numberToken := lexer.scanValue(Number)
 			return []Token{copyToken, numberToken}, nil
 		case UpperT, LowerT:
-			slideToken := Token{Type: Slide, Literal: lexer.currentToken, Line: lexer.currentLine, Column: lexer.currentColumn}
+			slideToken := lexer.newToken(Slide)
 			numberToken := lexer.scanValue(Number)
 			return []Token{slideToken, numberToken}, nil
 		default:
@@ -83,13 +129,13 @@ func (lexer *Lexer) scanStackManipulation() ([]Token, error) {
 		char = lexer.readNextChar()
 		switch char {
 		case UpperF, LowerF:
-			duplicateToken := Token{Type: Duplicate, Literal: lexer.currentToken, Line: lexer.currentLine, Column: lexer.currentColumn}
+			duplicateToken := lexer.newToken(Duplicate)
 			return []Token{duplicateToken}, nil
 		case UpperL, LowerL:
-			swapToken := Token{Type: Swap, Literal: lexer.currentToken, Line: lexer.currentLine, Column: lexer.currentColumn}
+			swapToken := lexer.newToken(Swap)
 			return []Token{swapToken}, nil
 		case UpperT, LowerT:
-			discardToken := Token{Type: Discard, Literal: lexer.currentToken, Line: lexer.currentLine, Column: lexer.currentColumn}
+			discardToken := lexer.newToken(Discard)
 			return []Token{discardToken}, nil
 		default:
 			return []Token{}, lexicalError(lexer, "expected stack manipulation command")
@@ -106,13 +152,13 @@ func (lexer *Lexer) scanArithmetic() ([]Token, error) {
 		char = lexer.readNextChar()
 		switch char {
 		case UpperF, LowerF:
-			additionToken := Token{Type: Addition, Literal: lexer.currentToken, Line: lexer.currentLine, Column: lexer.currentColumn}
+			additionToken := lexer.newToken(Addition)
 			return []Token{additionToken}, nil
 		case UpperL, LowerL:
-			subtractionToken := Token{Type: Subtraction, Literal: lexer.currentToken, Line: lexer.currentLine, Column: lexer.currentColumn}
+			subtractionToken := lexer.newToken(Subtraction)
 			return []Token{subtractionToken}, nil
 		case UpperT, LowerT:
-			multiplicationToken := Token{Type: Multiplication, Literal: lexer.currentToken, Line: lexer.currentLine, Column: lexer.currentColumn}
+			multiplicationToken := lexer.newToken(Multiplication)
 			return []Token{multiplicationToken}, nil
 		default:
 			return []Token{}, lexicalError(lexer, "expected artithemetic command")
@@ -121,10 +167,10 @@ func (lexer *Lexer) scanArithmetic() ([]Token, error) {
 		char = lexer.readNextChar()
 		switch char {
 		case UpperF, LowerF:
-			divisionToken := Token{Type: Division, Literal: lexer.currentToken, Line: lexer.currentLine, Column: lexer.currentColumn}
+			divisionToken := lexer.newToken(Division)
 			return []Token{divisionToken}, nil
 		case UpperL, LowerL:
-			moduloToken := Token{Type: Modulo, Literal: lexer.currentToken, Line: lexer.currentLine, Column: lexer.currentColumn}
+			moduloToken := lexer.newToken(Modulo)
 			return []Token{moduloToken}, nil
 		default:
 			return []Token{}, lexicalError(lexer, "expected artithemetic command")
@@ -138,10 +184,10 @@ func (lexer *Lexer) scanHeapAccess() ([]Token, error) {
 	char := lexer.readNextChar()
 	switch char {
 	case UpperF, LowerF:
-		storeToken := Token{Type: Store, Literal: lexer.currentToken, Line: lexer.currentLine, Column: lexer.currentColumn}
+		storeToken := lexer.newToken(Store)
 		return []Token{storeToken}, nil
 	case UpperL, LowerL:
-		retrieveToken := Token{Type: Retrieve, Literal: lexer.currentToken, Line: lexer.currentLine, Column: lexer.currentColumn}
+		retrieveToken := lexer.newToken(Retrieve)
 		return []Token{retrieveToken}, nil
 	default:
 		return []Token{}, lexicalError(lexer, "expected heap access command")
@@ -155,10 +201,10 @@ func (lexer *Lexer) scanIO() ([]Token, error) {
 		char = lexer.readNextChar()
 		switch char {
 		case UpperF, LowerF:
-			putcToken := Token{Type: Putc, Literal: lexer.currentToken, Line: lexer.currentLine, Column: lexer.currentColumn}
+			putcToken := lexer.newToken(Putc)
 			return []Token{putcToken}, nil
 		case UpperL, LowerL:
-			putnToken := Token{Type: Putn, Literal: lexer.currentToken, Line: lexer.currentLine, Column: lexer.currentColumn}
+			putnToken := lexer.newToken(Putn)
 			return []Token{putnToken}, nil
 		default:
 			return []Token{}, lexicalError(lexer, "expected IO command")
@@ -167,10 +213,10 @@ func (lexer *Lexer) scanIO() ([]Token, error) {
 		char = lexer.readNextChar()
 		switch char {
 		case UpperF, LowerF:
-			getcToken := Token{Type: Getc, Literal: lexer.currentToken, Line: lexer.currentLine, Column: lexer.currentColumn}
+			getcToken := lexer.newToken(Getc)
 			return []Token{getcToken}, nil
 		case UpperL, LowerL:
-			getnToken := Token{Type: Getn, Literal: lexer.currentToken, Line: lexer.currentLine, Column: lexer.currentColumn}
+			getnToken := lexer.newToken(Getn)
 			return []Token{getnToken}, nil
 		default:
 			return []Token{}, lexicalError(lexer, "expected IO command")
@@ -187,15 +233,15 @@ func (lexer *Lexer) scanFlowControll() ([]Token, error) {
 		char = lexer.readNextChar()
 		switch char {
 		case UpperF, LowerF:
-			markLabelToken := Token{Type: MarkLabel, Literal: lexer.currentToken, Line: lexer.currentLine, Column: lexer.currentColumn}
+			markLabelToken := lexer.newToken(MarkLabel)
 			labelToken := lexer.scanValue(Label)
 			return []Token{markLabelToken, labelToken}, nil
 		case UpperL, LowerL:
-			callSubroutineToken := Token{Type: CallSubroutine, Literal: lexer.currentToken, Line: lexer.currentLine, Column: lexer.currentColumn}
+			callSubroutineToken := lexer.newToken(CallSubroutine)
 			labelToken := lexer.scanValue(Label)
 			return []Token{callSubroutineToken, labelToken}, nil
 		case UpperT, LowerT:
-			jumpLabelToken := Token{Type: JumpLabel, Literal: lexer.currentToken, Line: lexer.currentLine, Column: lexer.currentColumn}
+			jumpLabelToken := lexer.newToken(JumpLabel)
 			labelToken := lexer.scanValue(Label)
 			return []Token{jumpLabelToken, labelToken}, nil
 		default:
@@ -205,15 +251,15 @@ func (lexer *Lexer) scanFlowControll() ([]Token, error) {
 		char = lexer.readNextChar()
 		switch char {
 		case UpperF, LowerF:
-			jumpLabelWhenZeroToken := Token{Type: JumpLabelWhenZero, Literal: lexer.currentToken, Line: lexer.currentLine, Column: lexer.currentColumn}
+			jumpLabelWhenZeroToken := lexer.newToken(JumpLabelWhenZero)
 			labelToken := lexer.scanValue(Label)
 			return []Token{jumpLabelWhenZeroToken, labelToken}, nil
 		case UpperL, LowerL:
-			jumpLabelWhenNegativeToken := Token{Type: JumpLabelWhenNegative, Literal: lexer.currentToken, Line: lexer.currentLine, Column: lexer.currentColumn}
+			jumpLabelWhenNegativeToken := lexer.newToken(JumpLabelWhenNegative)
 			labelToken := lexer.scanValue(Label)
 			return []Token{jumpLabelWhenNegativeToken, labelToken}, nil
 		case UpperT, LowerT:
-			endSubroutineToken := Token{Type: EndSubroutine, Literal: lexer.currentToken, Line: lexer.currentLine, Column: lexer.currentColumn}
+			endSubroutineToken := lexer.newToken(EndSubroutine)
 			return []Token{endSubroutineToken}, nil
 		default:
 			return []Token{}, lexicalError(lexer, "expected flow controll command")
@@ -221,7 +267,7 @@ func (lexer *Lexer) scanFlowControll() ([]Token, error) {
 	case UpperT, LowerT:
 		char = lexer.readNextChar()
 		if char == UpperT || char == LowerT {
-			endProgramToken := Token{Type: EndProgram, Literal: lexer.currentToken, Line: lexer.currentLine, Column: lexer.currentColumn}
+			endProgramToken := lexer.newToken(EndProgram)
 			return []Token{endProgramToken}, nil
 		} else {
 			return []Token{}, lexicalError(lexer, "expected flow controll command")
@@ -233,50 +279,86 @@ func (lexer *Lexer) scanFlowControll() ([]Token, error) {
 
 func (lexer *Lexer) scanValue(tokenType TokenType) Token {
 	lexer.currentToken = ""
-	var result string
+	lexer.tokenStart = lexer.tokenEnd
 	char := lexer.readNextChar()
-	result = char
 	for char != UpperT && char != LowerT && char != nullString() {
 		char = lexer.readNextChar()
-		result += char
 	}
 
-	return Token{Type: tokenType, Literal: lexer.currentToken, Line: lexer.currentLine, Column: lexer.currentColumn}
+	return lexer.newToken(tokenType)
 }
 
 func (lexer *Lexer) readNextChar() string {
-	lexer.currentIndex++
-	lexer.currentColumn++
-
-	if lexer.currentIndex >= len(lexer.source) {
-		return nullString()
-	}
-
-	if lexer.currentChar() == LF {
-		lexer.currentLine++
-		lexer.currentColumn = 0
-	}
-
-	for !isAcceptableCharacter(lexer.currentChar()) {
-		lexer.currentIndex++
-		lexer.currentColumn++
+	for {
+		lexer.advance()
 
 		if lexer.currentIndex >= len(lexer.source) {
 			return nullString()
 		}
 
-		if lexer.currentChar() == LF {
-			lexer.currentLine++
+		if symbol, char, ok := lexer.alphabet.match(lexer.source[lexer.currentIndex:]); ok {
+			lexer.currentWidth = len(symbol)
+			if lexer.currentToken == "" {
+				lexer.tokenStart = lexer.position()
+			}
+			lexer.tokenEnd = lexer.lastRunePosition()
+			lexer.currentToken += char
+			return char
+		}
+
+		_, lexer.currentWidth = utf8.DecodeRuneInString(lexer.source[lexer.currentIndex:])
+	}
+}
+
+// advance moves past the current symbol or ignored rune. The line is counted
+// after a LF so that a LF which is part of the alphabet is on the line it
+// ends.
+func (lexer *Lexer) advance() {
+	if lexer.currentIndex >= 0 && lexer.currentIndex < len(lexer.source) {
+		char := lexer.currentChar()
+		if lines := strings.Count(char, LF); lines > 0 {
+			lexer.currentLine += lines
 			lexer.currentColumn = 0
+		} else {
+			lexer.currentColumn += utf8.RuneCountInString(char) - 1
 		}
 	}
 
-	lexer.currentToken += lexer.currentChar()
-	return lexer.currentChar()
+	lexer.currentIndex += lexer.currentWidth
+	lexer.currentColumn++
+}
+
+func (lexer Lexer) position() Position {
+	return Position{Offset: lexer.currentIndex, Line: lexer.currentLine, Column: lexer.currentColumn}
+}
+
+// lastRunePosition returns the position of the last rune of the current
+// symbol.
+func (lexer Lexer) lastRunePosition() Position {
+	char := lexer.currentChar()
+	_, size := utf8.DecodeLastRuneInString(char)
+	return Position{
+		Offset: lexer.currentIndex + len(char) - size,
+		Line:   lexer.currentLine,
+		Column: lexer.currentColumn + utf8.RuneCountInString(char) - 1,
+	}
+}
+
+// newToken returns a token of the characters read since currentToken was
+// reset. Line and Column are those of the last character.
+func (lexer Lexer) newToken(tokenType TokenType) Token {
+	return Token{
+		Type:    tokenType,
+		Literal: lexer.currentToken,
+		Line:    lexer.tokenEnd.Line,
+		Column:  lexer.tokenEnd.Column,
+		Start:   lexer.tokenStart,
+		End:     lexer.tokenEnd,
+	}
 }
 
 func (lexer Lexer) currentChar() string {
-	return string(lexer.source[lexer.currentIndex])
+	return lexer.source[lexer.currentIndex:min(lexer.currentIndex+lexer.currentWidth, len(lexer.source))]
 }
 
 func (lexer *Lexer) addToken(token Token) {
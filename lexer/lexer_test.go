@@ -6,6 +6,17 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+// endPositions drops Start and End to compare Line and Column only.
+func endPositions(tokens []Token) []Token {
+	result := []Token{}
+	for _, token := range tokens {
+		token.Start = Position{}
+		token.End = Position{}
+		result = append(result, token)
+	}
+	return result
+}
+
 func TestScanStackManipulationToken(t *testing.T) {
 	source := "FFFFFT" + "FTF" + "FTL" + "FTT" + "FLFFLT" + "FLTFLT"
 
@@ -32,7 +43,7 @@ func TestScanStackManipulationToken(t *testing.T) {
 		t.Errorf("expected scan stack manipulate token, but raise error %s", err.Error())
 	}
 
-	assert.Equal(t, expectedTokens, tokens)
+	assert.Equal(t, expectedTokens, endPositions(tokens))
 }
 
 func TestScanArithmeticToken(t *testing.T) {
@@ -52,7 +63,7 @@ func TestScanArithmeticToken(t *testing.T) {
 		t.Errorf("expected scan arithmetic token, but raise error %s", err.Error())
 	}
 
-	assert.Equal(t, expectedTokens, tokens)
+	assert.Equal(t, expectedTokens, endPositions(tokens))
 }
 
 func TestScanHeapAccessToken(t *testing.T) {
@@ -69,7 +80,7 @@ func TestScanHeapAccessToken(t *testing.T) {
 		t.Errorf("expected scan heap access token, but raise error %s", err.Error())
 	}
 
-	assert.Equal(t, expectedTokens, tokens)
+	assert.Equal(t, expectedTokens, endPositions(tokens))
 }
 
 func TestScanIOToken(t *testing.T) {
@@ -88,7 +99,7 @@ func TestScanIOToken(t *testing.T) {
 		t.Errorf("expected scan IO token, but raise error %s", err.Error())
 	}
 
-	assert.Equal(t, expectedTokens, tokens)
+	assert.Equal(t, expectedTokens, endPositions(tokens))
 }
 
 func TestScanFlowControllToken(t *testing.T) {
@@ -121,7 +132,7 @@ func TestScanFlowControllToken(t *testing.T) {
 		t.Errorf("expected scan flow controll token, but raise error %s", err.Error())
 	}
 
-	assert.Equal(t, expectedTokens, tokens)
+	assert.Equal(t, expectedTokens, endPositions(tokens))
 }
 
 func TestScanTokenWithOtherCharacter(t *testing.T) {
@@ -138,7 +149,7 @@ func TestScanTokenWithOtherCharacter(t *testing.T) {
 		t.Errorf("expected scan token, but raise error %s", err.Error())
 	}
 
-	assert.Equal(t, expectedTokens, tokens)
+	assert.Equal(t, expectedTokens, endPositions(tokens))
 }
 
 func TestScanTokenWithDownCaseCharacter(t *testing.T) {
@@ -155,7 +166,7 @@ func TestScanTokenWithDownCaseCharacter(t *testing.T) {
 		t.Errorf("expected scan token, but raise error %s", err.Error())
 	}
 
-	assert.Equal(t, expectedTokens, tokens)
+	assert.Equal(t, expectedTokens, endPositions(tokens))
 }
 
 func TestScanInvalidToken(t *testing.T) {
@@ -171,3 +182,105 @@ func TestScanInvalidToken(t *testing.T) {
 
 	assert.NotNil(t, err)
 }
+
+func TestScanMultipleInvalidTokens(t *testing.T) {
+	source := "FLL" + "FFFLT" + "LLT" + "LTLF"
+
+	// scanning restarts one symbol after the start of each invalid
+	// instruction, so its symbols can start the next one
+	expectedTokens := []Token{
+		Token{Type: Store, Literal: "LLF", Line: 1, Column: 4},
+
+		Token{Type: Push, Literal: "FF", Line: 1, Column: 6},
+		Token{Type: Number, Literal: "LT", Line: 1, Column: 8},
+
+		Token{Type: EndSubroutine, Literal: "TLT", Line: 1, Column: 13},
+	}
+
+	tokens, err := ScanAllTokens(source, "")
+
+	assert.Equal(t, expectedTokens, endPositions(tokens))
+
+	var errs ErrorList
+	assert.ErrorAs(t, err, &errs)
+	assert.Equal(t, 3, len(errs))
+
+	var syntaxErr *SyntaxError
+	assert.ErrorAs(t, err, &syntaxErr)
+	assert.Equal(t, 1, syntaxErr.Line)
+	assert.Equal(t, 3, syntaxErr.Column)
+	assert.Equal(t, "Syntex error: expected stack manipulation command at :1:3", errs[0].Error())
+	assert.Equal(t, "Syntex error: expected heap access command at :1:11", errs[1].Error())
+	assert.Equal(t, "Syntex error: expected artithemetic command at :1:16", errs[2].Error())
+}
+
+func TestScanInvalidTokenResync(t *testing.T) {
+	source := "FFFT" + "LFT" + "TT"
+
+	// the F and T read by the invalid "LFT" start the next instruction
+	expectedTokens := []Token{
+		Token{Type: Push, Literal: "FF", Line: 1, Column: 2},
+		Token{Type: Number, Literal: "FT", Line: 1, Column: 4},
+
+		Token{Type: Discard, Literal: "FTT", Line: 1, Column: 8},
+	}
+
+	tokens, err := ScanAllTokens(source, "")
+
+	assert.Equal(t, expectedTokens, endPositions(tokens))
+
+	var errs ErrorList
+	assert.ErrorAs(t, err, &errs)
+	assert.Equal(t, 2, len(errs))
+	assert.Equal(t, "Syntex error: expected artithemetic command at :1:7", errs[0].Error())
+	assert.Equal(t, "Syntex error: expected flow controll command at :1:10", errs[1].Error())
+}
+
+func TestScanWhitespace(t *testing.T) {
+	source := "  \t\t\n" + "x\t\n  "
+
+	expectedTokens := []Token{
+		Token{Type: Push, Literal: "FF", Line: 1, Column: 2},
+		Token{Type: Number, Literal: "LLT", Line: 1, Column: 5},
+
+		Token{Type: Putc, Literal: "LTFF", Line: 3, Column: 2},
+	}
+
+	tokens, err := ScanAllTokensWithAlphabet(source, "", WhitespaceAlphabet)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expectedTokens, endPositions(tokens))
+}
+
+func TestScanTokenPositions(t *testing.T) {
+	source := "# コメント\n  FF FLT\tLTFL"
+
+	expectedTokens := []Token{
+		Token{Type: Push, Literal: "FF", Line: 2, Column: 4,
+			Start: Position{Offset: 17, Line: 2, Column: 3}, End: Position{Offset: 18, Line: 2, Column: 4}},
+		Token{Type: Number, Literal: "FLT", Line: 2, Column: 8,
+			Start: Position{Offset: 20, Line: 2, Column: 6}, End: Position{Offset: 22, Line: 2, Column: 8}},
+		Token{Type: Putn, Literal: "LTFL", Line: 2, Column: 13,
+			Start: Position{Offset: 24, Line: 2, Column: 10}, End: Position{Offset: 27, Line: 2, Column: 13}},
+	}
+
+	tokens, err := ScanAllTokens(source, "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, expectedTokens, tokens)
+
+	_, err = ScanAllTokens("# コメント\nFX", "test.fflt")
+
+	assert.EqualError(t, err, "Syntex error: expected stack manipulation command at test.fflt:2:3")
+}
+
+func TestSyntaxErrorUnderline(t *testing.T) {
+	source := "FFLT\nLTT FTF\n"
+
+	_, err := ScanAllTokens(source, "test.fflt")
+
+	var syntaxErr *SyntaxError
+	assert.ErrorAs(t, err, &syntaxErr)
+	assert.Equal(t, "LTT FTF\n^^^", syntaxErr.Span.Underline(source))
+	assert.Equal(t, "", Span{}.Underline(source))
+}
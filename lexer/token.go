@@ -1,5 +1,7 @@
 package lexer
 
+import "strings"
+
 const (
 	UpperF = "F"
 	LowerF = "f"
@@ -46,22 +48,68 @@ const (
 	Label  = TokenType("Label")
 )
 
+// Position is a place in the source. Offset is in bytes from the beginning
+// of the source. Line and Column start at 1, and Column counts runes from the
+// beginning of the line.
+type Position struct {
+	Offset int
+	Line   int
+	Column int
+}
+
+// Span is the source from Start to End, both inclusive.
+type Span struct {
+	Start Position
+	End   Position
+}
+
+// Underline returns the first source line of span with the span marked
+// by carets below it, or "" when the span is unknown.
+func (span Span) Underline(source string) string {
+	start, end := span.Start, span.End
+	if start.Line == 0 || start.Offset > len(source) {
+		return ""
+	}
+
+	lineStart := strings.LastIndex(source[:start.Offset], "\n") + 1
+	line := source[lineStart:]
+	if i := strings.Index(line, "\n"); i >= 0 {
+		line = line[:i]
+	}
+
+	var marker strings.Builder
+	column := 0
+	for _, char := range line {
+		column++
+		switch {
+		case column < start.Column && char == '\t':
+			marker.WriteRune('\t')
+		case column < start.Column:
+			marker.WriteRune(' ')
+		case end.Line == start.Line && column > end.Column:
+		default:
+			marker.WriteRune('^')
+		}
+	}
+
+	return line + "\n" + strings.TrimRight(marker.String(), " ")
+}
+
+// Token is a command, a number or a label. Start and End are the positions
+// of its first and last characters, and Line and Column are those of End.
 type Token struct {
 	Type    TokenType
 	Literal string
 	Line    int
 	Column  int
-}
-
-func isAcceptableCharacter(char string) bool {
-	return char == UpperF ||
-		char == LowerF ||
-		char == UpperL ||
-		char == LowerL ||
-		char == UpperT ||
-		char == LowerT
+	Start   Position
+	End     Position
 }
 
 func nullString() string {
 	return string([]byte{0})
 }
+
+func (token Token) Span() Span {
+	return Span{Start: token.Start, End: token.End}
+}
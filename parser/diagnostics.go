@@ -0,0 +1,142 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/simomu-github/fflt_lang/executor"
+	"github.com/simomu-github/fflt_lang/lexer"
+)
+
+type Severity string
+
+const (
+	SeverityError   = Severity("Error")
+	SeverityWarning = Severity("Warning")
+)
+
+type Position struct {
+	Filename string
+	Line     int
+	Column   int
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%s:%d:%d", p.Filename, p.Line, p.Column)
+}
+
+type Diagnostic struct {
+	Severity Severity
+	Message  string
+	Position Position
+	Related  []Position
+	// Span is the source of the instruction the diagnostic is about.
+	Span lexer.Span
+}
+
+func (d Diagnostic) String() string {
+	str := fmt.Sprintf("%s: %s at %s", d.Severity, d.Message, d.Position)
+	for _, related := range d.Related {
+		str += fmt.Sprintf(" (see %s)", related)
+	}
+	return str
+}
+
+// Underline underlines the instruction the diagnostic is about in source.
+func (d Diagnostic) Underline(source string) string {
+	return d.Span.Underline(source)
+}
+
+type Diagnostics []Diagnostic
+
+func (diagnostics Diagnostics) HasErrors() bool {
+	for _, d := range diagnostics {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+type labelReference struct {
+	label string
+	token lexer.Token
+	span  lexer.Span
+}
+
+// Diagnose reports duplicate label definitions, labels which are never
+// targeted and jump or call targets which are never defined.
+func Diagnose(instructions []executor.Instruction, filename string) Diagnostics {
+	definitions := map[string][]labelReference{}
+	definitionOrder := []string{}
+	references := []labelReference{}
+
+	for _, instruction := range instructions {
+		switch ins := instruction.(type) {
+		case executor.MarkLabel:
+			if _, ok := definitions[ins.Label]; !ok {
+				definitionOrder = append(definitionOrder, ins.Label)
+			}
+			definitions[ins.Label] = append(definitions[ins.Label], labelReference{label: ins.Label, token: ins.Token(), span: ins.Span()})
+		case executor.CallSubroutine:
+			references = append(references, labelReference{label: ins.Label, token: ins.Token(), span: ins.Span()})
+		case executor.JumpLabel:
+			references = append(references, labelReference{label: ins.Label, token: ins.Token(), span: ins.Span()})
+		case executor.JumpLabelWhenZero:
+			references = append(references, labelReference{label: ins.Label, token: ins.Token(), span: ins.Span()})
+		case executor.JumpLabelWhenNegative:
+			references = append(references, labelReference{label: ins.Label, token: ins.Token(), span: ins.Span()})
+		}
+	}
+
+	diagnostics := Diagnostics{}
+	referenced := map[string]bool{}
+
+	for _, reference := range references {
+		referenced[reference.label] = true
+		if _, ok := definitions[reference.label]; !ok {
+			diagnostics = append(diagnostics, Diagnostic{
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("label \"%s\" is not found", reference.label),
+				Position: tokenPosition(filename, reference.token),
+				Span:     reference.span,
+			})
+		}
+	}
+
+	for _, label := range definitionOrder {
+		marks := definitions[label]
+		for _, mark := range marks[1:] {
+			diagnostics = append(diagnostics, Diagnostic{
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("label \"%s\" is already defined", label),
+				Position: tokenPosition(filename, mark.token),
+				Related:  []Position{tokenPosition(filename, marks[0].token)},
+				Span:     mark.span,
+			})
+		}
+
+		if !referenced[label] {
+			diagnostics = append(diagnostics, Diagnostic{
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("label \"%s\" is never used", label),
+				Position: tokenPosition(filename, marks[0].token),
+				Span:     marks[0].span,
+			})
+		}
+	}
+
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		a, b := diagnostics[i].Position, diagnostics[j].Position
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+
+	return diagnostics
+}
+
+func tokenPosition(filename string, token lexer.Token) Position {
+	return Position{Filename: filename, Line: token.Line, Column: token.Column}
+}
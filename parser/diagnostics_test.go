@@ -0,0 +1,103 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/simomu-github/fflt_lang/executor"
+	"github.com/simomu-github/fflt_lang/lexer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiagnoseDuplicateLabel(t *testing.T) {
+	first := lexer.Token{Type: lexer.MarkLabel, Literal: "TFF", Line: 1, Column: 3}
+	second := lexer.Token{Type: lexer.MarkLabel, Literal: "TFF", Line: 2, Column: 3}
+	jump := lexer.Token{Type: lexer.JumpLabel, Literal: "TFT", Line: 3, Column: 3}
+
+	instructions := []executor.Instruction{
+		executor.MarkLabel{Source: executor.NewSource(first, lexer.Span{}), Label: "F"},
+		executor.MarkLabel{Source: executor.NewSource(second, lexer.Span{}), Label: "F"},
+		executor.JumpLabel{Source: executor.NewSource(jump, lexer.Span{}), Label: "F", Target: 1},
+	}
+
+	expected := Diagnostics{
+		Diagnostic{
+			Severity: SeverityError,
+			Message:  "label \"F\" is already defined",
+			Position: Position{Filename: "a.fflt", Line: 2, Column: 3},
+			Related:  []Position{Position{Filename: "a.fflt", Line: 1, Column: 3}},
+		},
+	}
+
+	diagnostics := Diagnose(instructions, "a.fflt")
+
+	assert.Equal(t, expected, diagnostics)
+	assert.True(t, diagnostics.HasErrors())
+}
+
+func TestDiagnoseUnusedLabel(t *testing.T) {
+	mark := lexer.Token{Type: lexer.MarkLabel, Literal: "TFF", Line: 1, Column: 3}
+
+	instructions := []executor.Instruction{
+		executor.MarkLabel{Source: executor.NewSource(mark, lexer.Span{}), Label: "L"},
+	}
+
+	expected := Diagnostics{
+		Diagnostic{
+			Severity: SeverityWarning,
+			Message:  "label \"L\" is never used",
+			Position: Position{Filename: "a.fflt", Line: 1, Column: 3},
+		},
+	}
+
+	diagnostics := Diagnose(instructions, "a.fflt")
+
+	assert.Equal(t, expected, diagnostics)
+	assert.False(t, diagnostics.HasErrors())
+}
+
+func TestDiagnoseUndefinedLabel(t *testing.T) {
+	call := lexer.Token{Type: lexer.CallSubroutine, Literal: "TFL", Line: 1, Column: 3}
+	jump := lexer.Token{Type: lexer.JumpLabelWhenZero, Literal: "TLF", Line: 2, Column: 3}
+
+	instructions := []executor.Instruction{
+		executor.CallSubroutine{Source: executor.NewSource(call, lexer.Span{}), Label: "F"},
+		executor.JumpLabelWhenZero{Source: executor.NewSource(jump, lexer.Span{}), Label: "L"},
+	}
+
+	diagnostics := Diagnose(instructions, "a.fflt")
+
+	assert.Equal(t, 2, len(diagnostics))
+	assert.Equal(t, "Error: label \"F\" is not found at a.fflt:1:3", diagnostics[0].String())
+	assert.Equal(t, "Error: label \"L\" is not found at a.fflt:2:3", diagnostics[1].String())
+}
+
+func TestDiagnosticUnderline(t *testing.T) {
+	source := "# ラベル\n\tFFFLT TFF LLT\nTTT"
+	tokens, _ := lexer.ScanAllTokens(source, "a.fflt")
+	instructions, _, _ := ParseAll(tokens, "a.fflt")
+
+	diagnostics := Diagnose(instructions, "a.fflt")
+
+	assert.Equal(t, 1, len(diagnostics))
+	assert.Equal(t, "\tFFFLT TFF LLT\n\t      ^^^^^^^", diagnostics[0].Underline(source))
+	assert.Equal(t, "", Diagnostic{}.Underline(source))
+}
+
+func TestDiagnoseSeveralProblems(t *testing.T) {
+	// jump to the undefined L, mark F twice and mark the unused LL
+	source := "TFTLT\nTFFFT\nTFFFT\nTFFLLT\nTFTFT\nFFL"
+	tokens, _ := lexer.ScanAllTokens(source, "a.fflt")
+
+	diagnostics, err := Check(tokens, "a.fflt")
+	assert.EqualError(t, err, "Syntex error: expected numeric parameters end with a \"T\" or \"t\" at a.fflt:6:3")
+
+	messages := []string{}
+	for _, diagnostic := range diagnostics {
+		messages = append(messages, diagnostic.String())
+	}
+	assert.Equal(t, []string{
+		"Error: label \"L\" is not found at a.fflt:1:3",
+		"Error: label \"F\" is already defined at a.fflt:3:3 (see a.fflt:2:3)",
+		"Warning: label \"LL\" is never used at a.fflt:4:3",
+	}, messages)
+}
@@ -1,13 +1,17 @@
 package parser
 
 import (
-	"errors"
-	"fmt"
-
 	"github.com/simomu-github/fflt_lang/lexer"
 )
 
-func parseError(state parseState, token lexer.Token, message string) error {
-	errorMessage := fmt.Sprintf("Syntex error: %s at %s:%d:%d", message, state.filename, token.Line, token.Column)
-	return errors.New(errorMessage)
+// parseError reports message at token. span is the source of the instruction
+// the token belongs to, which is underlined.
+func parseError(state parseState, token lexer.Token, span lexer.Span, message string) error {
+	return &lexer.SyntaxError{
+		Filename: state.filename,
+		Line:     token.Line,
+		Column:   token.Column,
+		Message:  message,
+		Span:     span,
+	}
 }
@@ -0,0 +1,27 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/simomu-github/fflt_lang/executor"
+	"github.com/simomu-github/fflt_lang/lexer"
+)
+
+// ParseFile reads filename written with alphabet and parses it with
+// ParseAll, joining the syntax errors of both steps.
+func ParseFile(filename string, alphabet lexer.Alphabet) ([]executor.Instruction, map[string]int, error) {
+	bytes, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s can not read", filename)
+	}
+
+	tokens, lexErr := lexer.ScanAllTokensWithAlphabet(string(bytes), filename, alphabet)
+	instructions, labelMap, parseErr := ParseAll(tokens, filename)
+	if lexErr != nil || parseErr != nil {
+		return nil, nil, errors.Join(lexErr, parseErr)
+	}
+
+	return instructions, labelMap, nil
+}
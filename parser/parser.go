@@ -2,6 +2,7 @@ package parser
 
 import (
 	"fmt"
+	"math/big"
 
 	"github.com/simomu-github/fflt_lang/executor"
 	"github.com/simomu-github/fflt_lang/lexer"
@@ -14,22 +15,107 @@ type parseState struct {
 }
 
 func ParseAll(tokens []lexer.Token, filename string) ([]executor.Instruction, map[string]int, error) {
+	state, errs := parseInstructions(tokens, filename)
+
+	state, linkErrs := linkLabels(state)
+	errs = append(errs, linkErrs...)
+
+	if len(errs) > 0 {
+		return nil, nil, errs
+	}
+
+	return state.instructions, state.labelMap, nil
+}
+
+// Check parses tokens the way -check needs: it returns the diagnostics of
+// every instruction which could be parsed along with the syntax errors, so
+// that label problems are reported once, by Diagnose. The instructions are
+// not returned since their jump and call targets are not resolved.
+func Check(tokens []lexer.Token, filename string) (Diagnostics, error) {
+	state, errs := parseInstructions(tokens, filename)
+	return Diagnose(state.instructions, filename), errs.Err()
+}
+
+func parseInstructions(tokens []lexer.Token, filename string) (parseState, lexer.ErrorList) {
 	state := parseState{
 		filename:     filename,
 		instructions: []executor.Instruction{},
 		labelMap:     map[string]int{},
 	}
 
+	var errs lexer.ErrorList
+
 	for i := 0; i < len(tokens); i++ {
 		var err error
 
 		state, i, err = parseToken(state, tokens, i, filename)
 
 		if err != nil {
-			return nil, nil, err
+			errs = append(errs, err)
 		}
 	}
-	return state.instructions, state.labelMap, nil
+
+	return state, errs
+}
+
+func linkLabels(state parseState) (parseState, lexer.ErrorList) {
+	var errs lexer.ErrorList
+
+	for i, instruction := range state.instructions {
+		switch ins := instruction.(type) {
+		case executor.MarkLabel:
+			if state.labelMap[ins.Label] != i {
+				errs = append(errs, parseError(state, ins.Token(), ins.Span(), fmt.Sprintf("label \"%s\" is already defined", ins.Label)))
+			}
+		case executor.CallSubroutine:
+			target, err := resolveLabel(state, ins, ins.Label)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+
+			ins.Target = target
+			state.instructions[i] = ins
+		case executor.JumpLabel:
+			target, err := resolveLabel(state, ins, ins.Label)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+
+			ins.Target = target
+			state.instructions[i] = ins
+		case executor.JumpLabelWhenZero:
+			target, err := resolveLabel(state, ins, ins.Label)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+
+			ins.Target = target
+			state.instructions[i] = ins
+		case executor.JumpLabelWhenNegative:
+			target, err := resolveLabel(state, ins, ins.Label)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+
+			ins.Target = target
+			state.instructions[i] = ins
+		}
+	}
+
+	return state, errs
+}
+
+func resolveLabel(state parseState, instruction executor.Instruction, label string) (int, error) {
+	target, ok := state.labelMap[label]
+	if !ok {
+		return 0, parseError(state, instruction.Token(), instruction.Span(), fmt.Sprintf("label \"%s\" is not found", label))
+	}
+
+	return target, nil
 }
 
 func parseToken(state parseState, tokens []lexer.Token, index int, filename string) (parseState, int, error) {
@@ -37,10 +123,12 @@ func parseToken(state parseState, tokens []lexer.Token, index int, filename stri
 
 	if requireArgumentTokens(tokens[index]) {
 		if index+1 >= len(tokens) {
-			return state, index, parseError(state, tokens[index], "expected parameter token")
+			return state, index, parseError(state, tokens[index], tokens[index].Span(), "expected parameter token")
 		}
 		state, err = parseTokenWithParameter(state, tokens[index], tokens[index+1])
-		index++
+		if isParameterToken(tokens[index+1]) {
+			index++
+		}
 	} else {
 		state, err = parseSingleToken(state, tokens[index])
 	}
@@ -49,119 +137,129 @@ func parseToken(state parseState, tokens []lexer.Token, index int, filename stri
 }
 
 func parseSingleToken(state parseState, token lexer.Token) (parseState, error) {
+	source := executor.NewSource(token, token.Span())
+
 	switch token.Type {
 	case lexer.Swap:
-		state.instructions = append(state.instructions, executor.Swap{Token: token})
+		state.instructions = append(state.instructions, executor.Swap{Source: source})
 	case lexer.Duplicate:
-		state.instructions = append(state.instructions, executor.Duplicate{Token: token})
+		state.instructions = append(state.instructions, executor.Duplicate{Source: source})
 	case lexer.Discard:
-		state.instructions = append(state.instructions, executor.Discard{})
+		state.instructions = append(state.instructions, executor.Discard{Source: source})
 
 	case lexer.Addition:
-		state.instructions = append(state.instructions, executor.Addition{Token: token})
+		state.instructions = append(state.instructions, executor.Addition{Source: source})
 	case lexer.Subtraction:
-		state.instructions = append(state.instructions, executor.Subtraction{Token: token})
+		state.instructions = append(state.instructions, executor.Subtraction{Source: source})
 	case lexer.Multiplication:
-		state.instructions = append(state.instructions, executor.Multiplication{Token: token})
+		state.instructions = append(state.instructions, executor.Multiplication{Source: source})
 	case lexer.Division:
-		state.instructions = append(state.instructions, executor.Division{Token: token})
+		state.instructions = append(state.instructions, executor.Division{Source: source})
 	case lexer.Modulo:
-		state.instructions = append(state.instructions, executor.Modulo{Token: token})
+		state.instructions = append(state.instructions, executor.Modulo{Source: source})
 
 	case lexer.Store:
-		state.instructions = append(state.instructions, executor.Store{Token: token})
+		state.instructions = append(state.instructions, executor.Store{Source: source})
 	case lexer.Retrieve:
-		state.instructions = append(state.instructions, executor.Retrieve{Token: token})
+		state.instructions = append(state.instructions, executor.Retrieve{Source: source})
 
 	case lexer.Putc:
-		state.instructions = append(state.instructions, executor.Putc{Token: token})
+		state.instructions = append(state.instructions, executor.Putc{Source: source})
 	case lexer.Putn:
-		state.instructions = append(state.instructions, executor.Putn{Token: token})
+		state.instructions = append(state.instructions, executor.Putn{Source: source})
 	case lexer.Getc:
-		state.instructions = append(state.instructions, executor.Getc{Token: token})
+		state.instructions = append(state.instructions, executor.Getc{Source: source})
 	case lexer.Getn:
-		state.instructions = append(state.instructions, executor.Getn{Token: token})
+		state.instructions = append(state.instructions, executor.Getn{Source: source})
 
 	case lexer.EndSubroutine:
-		state.instructions = append(state.instructions, executor.EndSubroutine{Token: token})
+		state.instructions = append(state.instructions, executor.EndSubroutine{Source: source})
 	case lexer.EndProgram:
-		state.instructions = append(state.instructions, executor.EndProgram{})
+		state.instructions = append(state.instructions, executor.EndProgram{Source: source})
 	}
 
 	return state, nil
 }
 
 func parseTokenWithParameter(state parseState, token lexer.Token, nextToken lexer.Token) (parseState, error) {
+	span := token.Span()
+	if isParameterToken(nextToken) {
+		span.End = nextToken.End
+	}
+	source := executor.NewSource(token, span)
+
 	switch token.Type {
 	case lexer.Push:
-		value, err := parseNumber(state, nextToken)
+		value, err := parseNumber(state, nextToken, span)
 		if err != nil {
 			return state, err
 		}
 
-		state.instructions = append(state.instructions, executor.Push{Value: value})
+		state.instructions = append(state.instructions, executor.Push{Source: source, Value: value})
 	case lexer.Copy:
-		value, err := parseNumber(state, nextToken)
+		value, err := parseIndex(state, nextToken, span)
 		if err != nil {
 			return state, err
 		}
 
-		state.instructions = append(state.instructions, executor.Copy{Token: token, Value: value})
+		state.instructions = append(state.instructions, executor.Copy{Source: source, Value: value})
 	case lexer.Slide:
-		value, err := parseNumber(state, nextToken)
+		value, err := parseIndex(state, nextToken, span)
 		if err != nil {
 			return state, err
 		}
 
-		state.instructions = append(state.instructions, executor.Slide{Token: token, Value: value})
+		state.instructions = append(state.instructions, executor.Slide{Source: source, Value: value})
 	case lexer.MarkLabel:
-		label, err := parseLabel(state, nextToken)
+		label, err := parseLabel(state, nextToken, span)
 		if err != nil {
 			return state, err
 		}
 
-		state.labelMap[label] = len(state.instructions)
-		state.instructions = append(state.instructions, executor.MarkLabel{Label: label})
+		if _, ok := state.labelMap[label]; !ok {
+			state.labelMap[label] = len(state.instructions)
+		}
+		state.instructions = append(state.instructions, executor.MarkLabel{Source: source, Label: label})
 	case lexer.CallSubroutine:
-		label, err := parseLabel(state, nextToken)
+		label, err := parseLabel(state, nextToken, span)
 		if err != nil {
 			return state, err
 		}
 
-		state.instructions = append(state.instructions, executor.CallSubroutine{Token: token, Label: label})
+		state.instructions = append(state.instructions, executor.CallSubroutine{Source: source, Label: label})
 	case lexer.JumpLabel:
-		label, err := parseLabel(state, nextToken)
+		label, err := parseLabel(state, nextToken, span)
 		if err != nil {
 			return state, err
 		}
 
-		state.instructions = append(state.instructions, executor.JumpLabel{Token: token, Label: label})
+		state.instructions = append(state.instructions, executor.JumpLabel{Source: source, Label: label})
 	case lexer.JumpLabelWhenZero:
-		label, err := parseLabel(state, nextToken)
+		label, err := parseLabel(state, nextToken, span)
 		if err != nil {
 			return state, err
 		}
 
-		state.instructions = append(state.instructions, executor.JumpLabelWhenZero{Token: token, Label: label})
+		state.instructions = append(state.instructions, executor.JumpLabelWhenZero{Source: source, Label: label})
 	case lexer.JumpLabelWhenNegative:
-		label, err := parseLabel(state, nextToken)
+		label, err := parseLabel(state, nextToken, span)
 		if err != nil {
 			return state, err
 		}
 
-		state.instructions = append(state.instructions, executor.JumpLabelWhenNegative{Token: token, Label: label})
+		state.instructions = append(state.instructions, executor.JumpLabelWhenNegative{Source: source, Label: label})
 	}
 
 	return state, nil
 }
 
-func parseNumber(state parseState, token lexer.Token) (int, error) {
+func parseNumber(state parseState, token lexer.Token, span lexer.Span) (executor.Number, error) {
 	if token.Type != lexer.Number {
-		return 0, parseError(state, token, fmt.Sprintf("expected number parameter, but actual %s token", token.Type))
+		return executor.Number{}, parseError(state, token, span, fmt.Sprintf("expected number parameter, but actual %s token", token.Type))
 	}
 
 	if len(token.Literal) == 0 {
-		return 0, parseError(state, token, "expected number parameter")
+		return executor.Number{}, parseError(state, token, span, "expected number parameter")
 	}
 
 	char := token.Literal[0]
@@ -173,48 +271,65 @@ func parseNumber(state parseState, token lexer.Token) (int, error) {
 	case lexer.UpperL, lexer.LowerL:
 		sign = -1
 	default:
-		return 0, parseError(state, token, "expected sign")
+		return executor.Number{}, parseError(state, token, span, "expected sign")
 	}
 
-	n, err := parseBinaryNumber(state, token, 0, 0)
+	n, err := parseBinaryNumber(state, token, span, new(big.Int), 0)
+	if err != nil {
+		return executor.Number{}, err
+	}
 
-	return sign * n, err
+	if sign < 0 {
+		n.Neg(n)
+	}
+
+	return executor.NewBigNumber(n), nil
 }
 
-func parseBinaryNumber(state parseState, token lexer.Token, n int, digits int) (int, error) {
+func parseIndex(state parseState, token lexer.Token, span lexer.Span) (int, error) {
+	value, err := parseNumber(state, token, span)
+	if err != nil {
+		return 0, err
+	}
+
+	if !value.IsInt() {
+		return 0, parseError(state, token, span, "number parameter is too large")
+	}
+
+	return value.Int(), nil
+}
+
+func parseBinaryNumber(state parseState, token lexer.Token, span lexer.Span, n *big.Int, digits int) (*big.Int, error) {
 	if digits+1 >= len(token.Literal) {
-		return 0, parseError(state, token, fmt.Sprintf("expected numeric parameters end with a \"T\" or \"t\""))
+		return nil, parseError(state, token, span, fmt.Sprintf("expected numeric parameters end with a \"T\" or \"t\""))
 	}
 
 	char := token.Literal[digits+1]
 	switch string(char) {
 	case lexer.UpperF, lexer.LowerF:
-		return parseBinaryNumber(state, token, 2*n, digits+1)
+		return parseBinaryNumber(state, token, span, n.Lsh(n, 1), digits+1)
 	case lexer.UpperL, lexer.LowerL:
-		return parseBinaryNumber(state, token, 2*n+1, digits+1)
+		return parseBinaryNumber(state, token, span, n.SetBit(n.Lsh(n, 1), 0, 1), digits+1)
 	case lexer.UpperT, lexer.LowerT:
-		if digits > 0 {
-			return n, nil
-		} else {
-			return 0, parseError(state, token, fmt.Sprintf("expected number parameter"))
-		}
+		// a number with no digits is 0 as in Whitespace
+		return n, nil
 	default:
-		return 0, parseError(state, token, fmt.Sprintf("expected numeric parameters end with a \"T\" or \"t\""))
+		return nil, parseError(state, token, span, fmt.Sprintf("expected numeric parameters end with a \"T\" or \"t\""))
 	}
 }
 
-func parseLabel(state parseState, token lexer.Token) (string, error) {
+func parseLabel(state parseState, token lexer.Token, span lexer.Span) (string, error) {
 	if token.Type != lexer.Label {
-		return "", parseError(state, token, fmt.Sprintf("expected label parameter, but actual %s token", token.Type))
+		return "", parseError(state, token, span, fmt.Sprintf("expected label parameter, but actual %s token", token.Type))
 	}
 
-	if len(token.Literal) <= 1 {
-		return "", parseError(state, token, fmt.Sprintf("expected label parameters"))
+	if len(token.Literal) == 0 {
+		return "", parseError(state, token, span, fmt.Sprintf("expected label parameters"))
 	}
 
 	lastChar := string(token.Literal[len(token.Literal)-1])
 	if lastChar != lexer.UpperT && lastChar != lexer.LowerT {
-		return "", parseError(state, token, fmt.Sprintf("expected label parameters end with a \"T\" or \"t\""))
+		return "", parseError(state, token, span, fmt.Sprintf("expected label parameters end with a \"T\" or \"t\""))
 	}
 
 	label := token.Literal[:len(token.Literal)-1]
@@ -231,3 +346,7 @@ func requireArgumentTokens(token lexer.Token) bool {
 		token.Type == lexer.JumpLabelWhenZero ||
 		token.Type == lexer.JumpLabelWhenNegative
 }
+
+func isParameterToken(token lexer.Token) bool {
+	return token.Type == lexer.Number || token.Type == lexer.Label
+}
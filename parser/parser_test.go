@@ -1,6 +1,9 @@
 package parser
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/simomu-github/fflt_lang/executor"
@@ -16,16 +19,36 @@ func TestParseNumber(t *testing.T) {
 	}
 
 	token := lexer.Token{Type: lexer.Number, Literal: "FLFT", Line: 0, Column: 0}
-	value, _ := parseNumber(state, token)
-	assert.Equal(t, 2, value)
+	value, _ := parseNumber(state, token, token.Span())
+	assert.Equal(t, executor.NewNumber(2), value)
 
 	token = lexer.Token{Type: lexer.Number, Literal: "FLLLLT", Line: 0, Column: 0}
-	value, _ = parseNumber(state, token)
-	assert.Equal(t, 15, value)
+	value, _ = parseNumber(state, token, token.Span())
+	assert.Equal(t, executor.NewNumber(15), value)
 
 	token = lexer.Token{Type: lexer.Number, Literal: "LLFT", Line: 0, Column: 0}
-	value, _ = parseNumber(state, token)
-	assert.Equal(t, -2, value)
+	value, _ = parseNumber(state, token, token.Span())
+	assert.Equal(t, executor.NewNumber(-2), value)
+}
+
+func TestParseBigNumber(t *testing.T) {
+	state := parseState{
+		filename:     "",
+		instructions: []executor.Instruction{},
+		labelMap:     map[string]int{},
+	}
+
+	token := lexer.Token{Type: lexer.Number, Literal: "L" + "L" + strings.Repeat("F", 64) + "T", Line: 0, Column: 0}
+	value, err := parseNumber(state, token, token.Span())
+
+	assert.Nil(t, err)
+	assert.Equal(t, "-18446744073709551616", value.String())
+
+	token = lexer.Token{Type: lexer.Copy, Literal: "FLF", Line: 0, Column: 0}
+	nextToken := lexer.Token{Type: lexer.Number, Literal: "F" + "L" + strings.Repeat("F", 64) + "T", Line: 0, Column: 0}
+	_, err = parseTokenWithParameter(state, token, nextToken)
+
+	assert.NotNil(t, err)
 }
 
 func TestParseInvalidNumber(t *testing.T) {
@@ -36,19 +59,15 @@ func TestParseInvalidNumber(t *testing.T) {
 	}
 
 	token := lexer.Token{Type: lexer.Number, Literal: "T", Line: 0, Column: 0}
-	_, err := parseNumber(state, token)
+	_, err := parseNumber(state, token, token.Span())
 	assert.NotNil(t, err)
 
 	token = lexer.Token{Type: lexer.Number, Literal: "F", Line: 0, Column: 0}
-	_, err = parseNumber(state, token)
-	assert.NotNil(t, err)
-
-	token = lexer.Token{Type: lexer.Number, Literal: "FT", Line: 0, Column: 0}
-	_, err = parseNumber(state, token)
+	_, err = parseNumber(state, token, token.Span())
 	assert.NotNil(t, err)
 
 	token = lexer.Token{Type: lexer.Number, Literal: "FF", Line: 0, Column: 0}
-	_, err = parseNumber(state, token)
+	_, err = parseNumber(state, token, token.Span())
 	assert.NotNil(t, err)
 }
 
@@ -60,8 +79,44 @@ func TestParseLabel(t *testing.T) {
 	}
 
 	token := lexer.Token{Type: lexer.Label, Literal: "FLFLT", Line: 0, Column: 0}
-	label, _ := parseLabel(state, token)
+	label, _ := parseLabel(state, token, token.Span())
 	assert.Equal(t, "FLFL", label)
+
+	token = lexer.Token{Type: lexer.Label, Literal: "T", Line: 0, Column: 0}
+	label, err := parseLabel(state, token, token.Span())
+	assert.NoError(t, err)
+	assert.Equal(t, "", label)
+}
+
+func TestParseNumberWithoutDigits(t *testing.T) {
+	state := parseState{
+		filename:     "",
+		instructions: []executor.Instruction{},
+		labelMap:     map[string]int{},
+	}
+
+	token := lexer.Token{Type: lexer.Number, Literal: "FT", Line: 0, Column: 0}
+	value, err := parseNumber(state, token, token.Span())
+	assert.NoError(t, err)
+	assert.Equal(t, executor.NewNumber(0), value)
+
+	token = lexer.Token{Type: lexer.Number, Literal: "LT", Line: 0, Column: 0}
+	value, err = parseNumber(state, token, token.Span())
+	assert.NoError(t, err)
+	assert.Equal(t, executor.NewNumber(0), value)
+}
+
+func TestParseWhitespace(t *testing.T) {
+	// push 0, putn, mark the empty label, end
+	tokens, err := lexer.ScanAllTokensWithAlphabet("   \n"+"\t\n \t"+"\n  \n"+"\n\n\n", "a.ws", lexer.WhitespaceAlphabet)
+	assert.NoError(t, err)
+
+	instructions, labelMap, err := ParseAll(tokens, "a.ws")
+	assert.NoError(t, err)
+	assert.Equal(t, 4, len(instructions))
+	assert.Equal(t, executor.NewNumber(0), instructions[0].(executor.Push).Value)
+	assert.Equal(t, "", instructions[2].(executor.MarkLabel).Label)
+	assert.Equal(t, map[string]int{"": 2}, labelMap)
 }
 
 func TestParseInvalidLabel(t *testing.T) {
@@ -72,11 +127,11 @@ func TestParseInvalidLabel(t *testing.T) {
 	}
 
 	token := lexer.Token{Type: lexer.Label, Literal: "F", Line: 0, Column: 0}
-	_, err := parseLabel(state, token)
+	_, err := parseLabel(state, token, token.Span())
 	assert.NotNil(t, err)
 
 	token = lexer.Token{Type: lexer.Label, Literal: "FLFL", Line: 0, Column: 0}
-	_, err = parseLabel(state, token)
+	_, err = parseLabel(state, token, token.Span())
 	assert.NotNil(t, err)
 }
 
@@ -89,10 +144,10 @@ func TestParseToken(t *testing.T) {
 	}
 
 	expectedInstructions := []executor.Instruction{
-		executor.Getc{Token: tokens[0]},
-		executor.Getn{Token: tokens[1]},
-		executor.Putc{Token: tokens[2]},
-		executor.Putn{Token: tokens[3]},
+		executor.Getc{Source: executor.NewSource(tokens[0], lexer.Span{})},
+		executor.Getn{Source: executor.NewSource(tokens[1], lexer.Span{})},
+		executor.Putc{Source: executor.NewSource(tokens[2], lexer.Span{})},
+		executor.Putn{Source: executor.NewSource(tokens[3], lexer.Span{})},
 	}
 
 	instructions, _, err := ParseAll(tokens, "")
@@ -129,13 +184,13 @@ func TestParseTokenWithParameter(t *testing.T) {
 	}
 
 	expectedInstructions := []executor.Instruction{
-		executor.Push{Value: 15},
-		executor.Copy{Token: tokens[2], Value: 7},
-		executor.Slide{Token: tokens[4], Value: 3},
-		executor.MarkLabel{Label: "L"},
-		executor.JumpLabel{Token: tokens[8], Label: "L"},
-		executor.JumpLabelWhenZero{Token: tokens[10], Label: "L"},
-		executor.JumpLabelWhenNegative{Token: tokens[12], Label: "L"},
+		executor.Push{Source: executor.NewSource(tokens[0], lexer.Span{}), Value: executor.NewNumber(15)},
+		executor.Copy{Source: executor.NewSource(tokens[2], lexer.Span{}), Value: 7},
+		executor.Slide{Source: executor.NewSource(tokens[4], lexer.Span{}), Value: 3},
+		executor.MarkLabel{Source: executor.NewSource(tokens[6], lexer.Span{}), Label: "L"},
+		executor.JumpLabel{Source: executor.NewSource(tokens[8], lexer.Span{}), Label: "L", Target: 3},
+		executor.JumpLabelWhenZero{Source: executor.NewSource(tokens[10], lexer.Span{}), Label: "L", Target: 3},
+		executor.JumpLabelWhenNegative{Source: executor.NewSource(tokens[12], lexer.Span{}), Label: "L", Target: 3},
 	}
 
 	expectedLabelMap := map[string]int{
@@ -190,8 +245,8 @@ func TestParseWhenExistsCallLabelBeforeMarkLabel(t *testing.T) {
 	}
 
 	expectedInstructions := []executor.Instruction{
-		executor.JumpLabel{Token: tokens[0], Label: "F"},
-		executor.MarkLabel{Label: "F"},
+		executor.JumpLabel{Source: executor.NewSource(tokens[0], lexer.Span{}), Label: "F", Target: 1},
+		executor.MarkLabel{Source: executor.NewSource(tokens[2], lexer.Span{}), Label: "F"},
 	}
 
 	expectedLabelMap := map[string]int{
@@ -207,3 +262,113 @@ func TestParseWhenExistsCallLabelBeforeMarkLabel(t *testing.T) {
 	assert.Equal(t, expectedInstructions, instructions)
 	assert.Equal(t, expectedLabelMap, labelMap)
 }
+
+func TestParseWhenLabelIsNotFound(t *testing.T) {
+	tokens := []lexer.Token{
+		lexer.Token{Type: lexer.MarkLabel, Literal: "TFF", Line: 1, Column: 3},
+		lexer.Token{Type: lexer.Label, Literal: "FT", Line: 1, Column: 5},
+
+		lexer.Token{Type: lexer.CallSubroutine, Literal: "TFL", Line: 1, Column: 8},
+		lexer.Token{Type: lexer.Label, Literal: "LT", Line: 1, Column: 10},
+	}
+
+	_, _, err := ParseAll(tokens, "")
+
+	assert.NotNil(t, err)
+}
+
+func TestParseMultipleInvalidTokens(t *testing.T) {
+	tokens := []lexer.Token{
+		lexer.Token{Type: lexer.Push, Literal: "FF", Line: 1, Column: 2},
+		lexer.Token{Type: lexer.Number, Literal: "F", Line: 1, Column: 4},
+
+		lexer.Token{Type: lexer.Copy, Literal: "FLF", Line: 1, Column: 7},
+		lexer.Token{Type: lexer.Putn, Literal: "LTFL", Line: 1, Column: 11},
+
+		lexer.Token{Type: lexer.JumpLabel, Literal: "TFT", Line: 1, Column: 14},
+		lexer.Token{Type: lexer.Label, Literal: "LT", Line: 1, Column: 16},
+	}
+
+	instructions, labelMap, err := ParseAll(tokens, "")
+
+	assert.Nil(t, instructions)
+	assert.Nil(t, labelMap)
+
+	var errs lexer.ErrorList
+	assert.ErrorAs(t, err, &errs)
+	assert.Equal(t, 3, len(errs))
+}
+
+func TestParseSpans(t *testing.T) {
+	tokens, _ := lexer.ScanAllTokens("FF\nFLT LTFL", "")
+	instructions, _, err := ParseAll(tokens, "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, lexer.Span{
+		Start: lexer.Position{Offset: 0, Line: 1, Column: 1},
+		End:   lexer.Position{Offset: 5, Line: 2, Column: 3},
+	}, instructions[0].Span())
+	assert.Equal(t, lexer.Span{
+		Start: lexer.Position{Offset: 7, Line: 2, Column: 5},
+		End:   lexer.Position{Offset: 10, Line: 2, Column: 8},
+	}, instructions[1].Span())
+}
+
+func TestParseErrorUnderline(t *testing.T) {
+	source := "FFLT\nTFFF"
+	tokens, _ := lexer.ScanAllTokens(source, "test.fflt")
+
+	_, _, err := ParseAll(tokens, "test.fflt")
+
+	var syntaxErr *lexer.SyntaxError
+	assert.ErrorAs(t, err, &syntaxErr)
+	assert.Equal(t, "TFFF\n^^^^", syntaxErr.Span.Underline(source))
+}
+
+func TestParseErrorUnderlineMatchesDiagnostic(t *testing.T) {
+	source := "FFLT\nTFT LT"
+	tokens, _ := lexer.ScanAllTokens(source, "test.fflt")
+
+	_, _, err := ParseAll(tokens, "test.fflt")
+	diagnostics, _ := Check(tokens, "test.fflt")
+
+	var syntaxErr *lexer.SyntaxError
+	assert.ErrorAs(t, err, &syntaxErr)
+	assert.Equal(t, "TFT LT\n^^^^^^", syntaxErr.Span.Underline(source))
+	assert.Equal(t, diagnostics[0].Underline(source), syntaxErr.Span.Underline(source))
+}
+
+func TestParseWhenLabelIsDuplicated(t *testing.T) {
+	tokens, _ := lexer.ScanAllTokens("TFF FT TFF FT", "")
+
+	instructions, labelMap, err := ParseAll(tokens, "")
+
+	assert.Nil(t, instructions)
+	assert.Nil(t, labelMap)
+
+	var syntaxErr *lexer.SyntaxError
+	assert.ErrorAs(t, err, &syntaxErr)
+	assert.Equal(t, "label \"F\" is already defined", syntaxErr.Message)
+	assert.Equal(t, 8, syntaxErr.Span.Start.Column)
+}
+
+func TestParseFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "a.fflt")
+	os.WriteFile(filename, []byte("FFFT LTFL\nTTT"), 0o644)
+
+	instructions, labelMap, err := ParseFile(filename, lexer.DefaultAlphabet)
+
+	assert.NoError(t, err)
+	assert.Len(t, instructions, 3)
+	assert.Equal(t, map[string]int{}, labelMap)
+
+	os.WriteFile(filename, []byte("TFT LT"), 0o644)
+
+	_, _, err = ParseFile(filename, lexer.DefaultAlphabet)
+
+	assert.ErrorContains(t, err, "label \"L\" is not found")
+
+	_, _, err = ParseFile(filepath.Join(t.TempDir(), "none.fflt"), lexer.DefaultAlphabet)
+
+	assert.ErrorContains(t, err, "can not read")
+}